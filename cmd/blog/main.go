@@ -13,7 +13,11 @@ Commands:
   serve                          start HTTP server
   new post <title>               create a new post (in content/private/)
   new project <name>             create a new project
+  new now                        archive the /now page and start a new one
   publish <slug>                 move post from private to public
+  rename <old-slug> <new-title>  rename a post, keeping the old slug as an alias
+  retract <slug> [reason]        remove a post and serve 410 Gone in its place
+  lint [dir]                     check content for problems
   dash                           admin dashboard
   comments                       list recent comments
   comments delete <id>           delete a comment
@@ -34,6 +38,12 @@ func main() {
 		blog.New(os.Args[2:])
 	case "publish":
 		blog.Publish(os.Args[2:])
+	case "rename":
+		blog.Rename(os.Args[2:])
+	case "retract":
+		blog.Retract(os.Args[2:])
+	case "lint":
+		blog.Lint(os.Args[2:])
 	case "dash":
 		blog.Dashboard()
 	case "comments":
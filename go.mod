@@ -3,11 +3,15 @@ module github.com/thobiasn/blog
 go 1.25.6
 
 require (
+	github.com/HugoSmits86/nativewebp v1.2.1
 	github.com/alecthomas/chroma/v2 v2.23.1
+	github.com/awalterschulze/gographviz v2.0.3+incompatible
+	github.com/wyatt915/treeblood v0.1.16
 	github.com/yuin/goldmark v1.7.16
-	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
 	go.abhg.dev/goldmark/anchor v0.2.0
 	go.abhg.dev/goldmark/frontmatter v0.3.0
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 	modernc.org/sqlite v1.46.1
 )
 
@@ -21,7 +25,7 @@ require (
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/text v0.23.0 // indirect
 	modernc.org/libc v1.67.6 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
@@ -0,0 +1,158 @@
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// postsPerPage is how many posts /posts shows before paginating.
+const postsPerPage = 20
+
+type archiveYear struct {
+	Year   int
+	Months []archiveMonth
+}
+
+type archiveMonth struct {
+	Year  int
+	Month time.Month
+	Posts []Post
+}
+
+type pagination struct {
+	Page       int
+	TotalPages int
+	PrevURL    string
+	NextURL    string
+}
+
+// paginate returns the posts on a 1-indexed page. ok is false if the page is
+// out of range; page 1 of an empty list is always valid.
+func paginate(posts []Post, page, perPage int) ([]Post, pagination, bool) {
+	total := (len(posts) + perPage - 1) / perPage
+	if total == 0 {
+		total = 1
+	}
+	if page < 1 || page > total {
+		return nil, pagination{}, false
+	}
+
+	p := pagination{Page: page, TotalPages: total}
+	if page > 1 {
+		p.PrevURL = "/posts"
+		if page > 2 {
+			p.PrevURL = fmt.Sprintf("/posts?page=%d", page-1)
+		}
+	}
+	if page < total {
+		p.NextURL = fmt.Sprintf("/posts?page=%d", page+1)
+	}
+
+	start := (page - 1) * perPage
+	end := min(start+perPage, len(posts))
+	return posts[start:end], p, true
+}
+
+// groupByMonth groups posts (sorted newest first) by year, then month.
+func groupByMonth(posts []Post) []archiveYear {
+	var years []archiveYear
+	for _, p := range posts {
+		y, m := p.Date.Year(), p.Date.Month()
+		if len(years) == 0 || years[len(years)-1].Year != y {
+			years = append(years, archiveYear{Year: y})
+		}
+		yr := &years[len(years)-1]
+		if len(yr.Months) == 0 || yr.Months[len(yr.Months)-1].Month != m {
+			yr.Months = append(yr.Months, archiveMonth{Year: y, Month: m})
+		}
+		mo := &yr.Months[len(yr.Months)-1]
+		mo.Posts = append(mo.Posts, p)
+	}
+	return years
+}
+
+// filterByDate returns posts from year, and from month too if it's non-zero.
+func filterByDate(posts []Post, year int, month time.Month) []Post {
+	var out []Post
+	for _, p := range posts {
+		if p.Date.Year() == year && (month == 0 || p.Date.Month() == month) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// parseArchivePath parses "/2026/" or "/2026/03/" into a year and optional
+// month. Anything else returns ok == false.
+func parseArchivePath(path string) (year int, month time.Month, ok bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 2 || len(parts[0]) != 4 {
+		return 0, 0, false
+	}
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || year < 1 {
+		return 0, 0, false
+	}
+	if len(parts) == 2 {
+		m, err := strconv.Atoi(parts[1])
+		if err != nil || len(parts[1]) != 2 || m < 1 || m > 12 {
+			return 0, 0, false
+		}
+		month = time.Month(m)
+	}
+	return year, month, true
+}
+
+func (app *App) handleArchive(w http.ResponseWriter, r *http.Request) {
+	app.mu.RLock()
+	posts := app.visiblePosts()
+	app.mu.RUnlock()
+
+	app.render(w, "archive", map[string]any{
+		"Title": "Archive",
+		"Years": groupByMonth(posts),
+	})
+}
+
+// handleDateArchive serves /{year}/ and /{year}/{month}/. It can't be a mux
+// pattern because /{year}/ would conflict with /static/ and /images/.
+func (app *App) handleDateArchive(w http.ResponseWriter, r *http.Request, year int, month time.Month) {
+	app.mu.RLock()
+	posts := filterByDate(app.visiblePosts(), year, month)
+	app.mu.RUnlock()
+
+	if len(posts) == 0 {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	title := strconv.Itoa(year)
+	if month != 0 {
+		title = month.String() + " " + title
+	}
+
+	app.render(w, "archive", map[string]any{
+		"Title": title,
+		"Years": groupByMonth(posts),
+	})
+}
+
+// handleFallback serves everything the mux has no explicit pattern for:
+// date archives, then content pages. A page whose path a registered route
+// also matches, like one under now/ or api/, never gets here; blog lint
+// reports those (see linter.shadowed).
+func (app *App) handleFallback(w http.ResponseWriter, r *http.Request) {
+	if year, month, ok := parseArchivePath(r.URL.Path); ok {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		app.handleDateArchive(w, r, year, month)
+		return
+	}
+
+	app.handlePage(w, r)
+}
@@ -0,0 +1,191 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func archiveTestPosts() []Post {
+	return []Post{
+		{Slug: "march-b", Title: "March B", Date: time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{Slug: "march-a", Title: "March A", Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{Slug: "feb", Title: "February", Date: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC)},
+		{Slug: "old", Title: "Last Year", Date: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestGroupByMonth(t *testing.T) {
+	years := groupByMonth(archiveTestPosts())
+	if len(years) != 2 {
+		t.Fatalf("got %d years, want 2", len(years))
+	}
+	if years[0].Year != 2026 || len(years[0].Months) != 2 {
+		t.Errorf("years[0] = %d with %d months, want 2026 with 2", years[0].Year, len(years[0].Months))
+	}
+	if m := years[0].Months[0]; m.Month != time.March || len(m.Posts) != 2 {
+		t.Errorf("first month = %s with %d posts, want March with 2", m.Month, len(m.Posts))
+	}
+	if years[1].Year != 2025 {
+		t.Errorf("years[1].Year = %d, want 2025", years[1].Year)
+	}
+
+	if groupByMonth(nil) != nil {
+		t.Error("groupByMonth(nil) should be nil")
+	}
+}
+
+func TestParseArchivePath(t *testing.T) {
+	tests := []struct {
+		path      string
+		wantYear  int
+		wantMonth time.Month
+		wantOK    bool
+	}{
+		{"/2026/", 2026, 0, true},
+		{"/2026", 2026, 0, true},
+		{"/2026/03/", 2026, time.March, true},
+		{"/2026/3/", 0, 0, false},
+		{"/2026/13/", 0, 0, false},
+		{"/2026/03/04/", 0, 0, false},
+		{"/uses", 0, 0, false},
+		{"/", 0, 0, false},
+		{"/abcd/", 0, 0, false},
+	}
+	for _, tt := range tests {
+		y, m, ok := parseArchivePath(tt.path)
+		if y != tt.wantYear || m != tt.wantMonth || ok != tt.wantOK {
+			t.Errorf("parseArchivePath(%q) = %d, %s, %v; want %d, %s, %v",
+				tt.path, y, m, ok, tt.wantYear, tt.wantMonth, tt.wantOK)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	posts := make([]Post, 45)
+
+	got, p, ok := paginate(posts, 1, 20)
+	if !ok || len(got) != 20 || p.TotalPages != 3 {
+		t.Fatalf("page 1: %d posts, %d pages, ok=%v", len(got), p.TotalPages, ok)
+	}
+	if p.PrevURL != "" || p.NextURL != "/posts?page=2" {
+		t.Errorf("page 1 links = %q, %q", p.PrevURL, p.NextURL)
+	}
+
+	_, p, _ = paginate(posts, 2, 20)
+	if p.PrevURL != "/posts" || p.NextURL != "/posts?page=3" {
+		t.Errorf("page 2 links = %q, %q", p.PrevURL, p.NextURL)
+	}
+
+	got, p, ok = paginate(posts, 3, 20)
+	if !ok || len(got) != 5 || p.NextURL != "" || p.PrevURL != "/posts?page=2" {
+		t.Errorf("page 3: %d posts, links %q, %q", len(got), p.PrevURL, p.NextURL)
+	}
+
+	if _, _, ok := paginate(posts, 4, 20); ok {
+		t.Error("page 4 should be out of range")
+	}
+	if _, _, ok := paginate(posts, 0, 20); ok {
+		t.Error("page 0 should be out of range")
+	}
+	if _, _, ok := paginate(nil, 1, 20); !ok {
+		t.Error("page 1 of no posts should be valid")
+	}
+}
+
+func TestHandlePostListPagination(t *testing.T) {
+	app := testApp(t)
+	app.posts = make([]Post, postsPerPage+1)
+	for i := range app.posts {
+		app.posts[i] = Post{Slug: "p", Title: "Post"}
+	}
+	app.posts[postsPerPage].Title = "Last One"
+
+	req := httptest.NewRequest("GET", "/posts", nil)
+	w := httptest.NewRecorder()
+	app.handlePostList(w, req)
+	body := w.Body.String()
+	if strings.Contains(body, "Last One") {
+		t.Error("page 1 should not contain the last post")
+	}
+	if !strings.Contains(body, `<link rel="next" href="/posts?page=2">`) {
+		t.Error("page 1 should have rel=next link")
+	}
+
+	req = httptest.NewRequest("GET", "/posts?page=2", nil)
+	w = httptest.NewRecorder()
+	app.handlePostList(w, req)
+	body = w.Body.String()
+	if !strings.Contains(body, "Last One") {
+		t.Error("page 2 should contain the last post")
+	}
+	if !strings.Contains(body, `<link rel="prev" href="/posts">`) {
+		t.Error("page 2 should have rel=prev link")
+	}
+
+	for _, q := range []string{"3", "0", "abc"} {
+		req = httptest.NewRequest("GET", "/posts?page="+q, nil)
+		w = httptest.NewRecorder()
+		app.handlePostList(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("page=%s: status = %d, want %d", q, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestHandleArchive(t *testing.T) {
+	app := testApp(t)
+	app.posts = archiveTestPosts()
+
+	req := httptest.NewRequest("GET", "/archive", nil)
+	w := httptest.NewRecorder()
+	app.handleArchive(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`href="/2026/"`, `href="/2026/03/"`, `href="/2025/12/"`, "Last Year"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("archive should contain %q", want)
+		}
+	}
+}
+
+func TestHandleFallbackDateArchive(t *testing.T) {
+	app := testApp(t)
+	app.posts = archiveTestPosts()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		want     string
+		notWant  string
+	}{
+		{"/2026/", http.StatusOK, "March B", "Last Year"},
+		{"/2026/02/", http.StatusOK, "February", "March B"},
+		{"/2026/03", http.StatusMovedPermanently, "", ""},
+		{"/2024/", http.StatusNotFound, "", ""},
+		{"/nope", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			app.handleFallback(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			body := w.Body.String()
+			if tt.want != "" && !strings.Contains(body, tt.want) {
+				t.Errorf("body should contain %q", tt.want)
+			}
+			if tt.notWant != "" && strings.Contains(body, tt.notWant) {
+				t.Errorf("body should not contain %q", tt.notWant)
+			}
+		})
+	}
+}
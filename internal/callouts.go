@@ -0,0 +1,138 @@
+package blog
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Callouts are written as GitHub alerts,
+//
+//	> [!WARNING]
+//	> This deletes the volume.
+//
+// or as a :::warning container, which can also take a title. Both render
+// as <aside class="callout callout-warning"> with the title as its first
+// paragraph, so they still read right in feeds, where there's no CSS, and
+// as plain text in the search index.
+
+var kindCallout = ast.NewNodeKind("Callout")
+
+// calloutTitles are the callout kinds, the same as GitHub's alert types,
+// and their default titles.
+var calloutTitles = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+}
+
+type calloutNode struct {
+	ast.BaseBlock
+	fence
+	Callout string
+	Title   string
+}
+
+func (n *calloutNode) Kind() ast.NodeKind { return kindCallout }
+
+func (n *calloutNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Callout": n.Callout, "Title": n.Title}, nil)
+}
+
+type calloutExtension struct{}
+
+func (calloutExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(alertTransformer{}, 500),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(calloutRenderer{}, 500),
+	))
+}
+
+var alertMarker = regexp.MustCompile(`^\[!(\w+)\]$`)
+
+// alertTransformer turns a blockquote whose first line is [!KIND] into a
+// callout. Unknown kinds are left as blockquotes.
+type alertTransformer struct{}
+
+func (alertTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var quotes []*ast.Blockquote
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if q, ok := n.(*ast.Blockquote); ok && entering {
+			quotes = append(quotes, q)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	source := reader.Source()
+	for _, q := range quotes {
+		p, ok := q.FirstChild().(*ast.Paragraph)
+		if !ok || p.Lines().Len() == 0 {
+			continue
+		}
+		first := p.Lines().At(0)
+		m := alertMarker.FindSubmatch(bytes.TrimSpace(first.Value(source)))
+		if m == nil {
+			continue
+		}
+		kind := strings.ToLower(string(m[1]))
+		if _, ok := calloutTitles[kind]; !ok {
+			continue
+		}
+
+		// drop the marker line; the rest of the paragraph is content
+		for c := p.FirstChild(); c != nil; {
+			t, ok := c.(*ast.Text)
+			if !ok || t.Segment.Start >= first.Stop {
+				break
+			}
+			next := c.NextSibling()
+			p.RemoveChild(p, c)
+			c = next
+		}
+		if !p.HasChildren() {
+			q.RemoveChild(q, p)
+		}
+
+		callout := &calloutNode{Callout: kind}
+		for c := q.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			callout.AppendChild(callout, c)
+			c = next
+		}
+		q.Parent().ReplaceChild(q.Parent(), q, callout)
+	}
+}
+
+type calloutRenderer struct{}
+
+func (calloutRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindCallout, renderCallout)
+}
+
+func renderCallout(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString("</aside>\n")
+		return ast.WalkContinue, nil
+	}
+	n := node.(*calloutNode)
+	title := n.Title
+	if title == "" {
+		title = calloutTitles[n.Callout]
+	}
+	w.WriteString(`<aside class="callout callout-` + n.Callout + `" role="note">` + "\n")
+	w.WriteString(`<p class="callout-title">`)
+	w.Write(util.EscapeHTML([]byte(title)))
+	w.WriteString("</p>\n")
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,86 @@
+package blog
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestAlertCallouts(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		want      string
+	}{
+		{
+			name: "note",
+			src:  "> [!NOTE]\n> Read *this* first.\n",
+			want: "<aside class=\"callout callout-note\" role=\"note\">\n<p class=\"callout-title\">Note</p>\n<p>Read <em>this</em> first.</p>\n</aside>",
+		},
+		{
+			name: "lowercase marker with blank line",
+			src:  "> [!warning]\n>\n> Deletes the volume.\n",
+			want: "<aside class=\"callout callout-warning\" role=\"note\">\n<p class=\"callout-title\">Warning</p>\n<p>Deletes the volume.</p>\n</aside>",
+		},
+		{
+			name: "marker must be alone on its line",
+			src:  "> [!TIP] not a callout\n",
+			want: "<blockquote>\n<p>[!TIP] not a callout</p>\n</blockquote>",
+		},
+		{
+			name: "unknown kind",
+			src:  "> [!FOO]\n> text\n",
+			want: "<blockquote>\n<p>[!FOO]\ntext</p>\n</blockquote>",
+		},
+		{
+			name: "plain blockquote",
+			src:  "> just a quote\n",
+			want: "<blockquote>\n<p>just a quote</p>\n</blockquote>",
+		},
+	} {
+		if out := renderMarkdown(t, tt.src); !strings.Contains(out, tt.want) {
+			t.Errorf("%s: missing %q\ngot: %s", tt.name, tt.want, out)
+		}
+	}
+}
+
+func TestFencedCallouts(t *testing.T) {
+	out := renderMarkdown(t, `:::tip
+Use `+"`blog lint`"+` before deploying.
+:::
+
+:::caution Breaking <change>
+Config moved.
+
+:::gallery
+![a](/images/a.png)
+:::
+
+Still inside.
+:::
+
+After.
+
+:::unknown
+text
+:::
+`)
+
+	for _, want := range []string{
+		"<aside class=\"callout callout-tip\" role=\"note\">\n<p class=\"callout-title\">Tip</p>\n<p>Use <code>blog lint</code> before deploying.</p>\n</aside>",
+		`<p class="callout-title">Breaking &lt;change&gt;</p>`,
+		"</div>\n<p>Still inside.</p>\n</aside>\n<p>After.</p>",
+		"<p>:::unknown\ntext\n:::</p>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q\ngot: %s", want, out)
+		}
+	}
+}
+
+func TestCalloutSearchText(t *testing.T) {
+	body := renderMarkdown(t, "> [!WARNING]\n> Back up first.\n")
+	text := strings.Join(strings.Fields(stripTags(template.HTML(body))), " ")
+	if text != "Warning Back up first." {
+		t.Errorf("search text = %q, want the title then the body", text)
+	}
+}
@@ -0,0 +1,29 @@
+package blog
+
+import (
+	"fmt"
+	"os"
+)
+
+func Lint(args []string) {
+	cfg := LoadConfig()
+	dir := cfg.ContentDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	issues, err := lintContent(dir, newMarkdown(includeExtension{dir: dir}))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, i := range issues {
+		fmt.Println(i)
+	}
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problem(s) found\n", len(issues))
+		os.Exit(1)
+	}
+	fmt.Println("no problems found")
+}
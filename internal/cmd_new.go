@@ -12,7 +12,7 @@ import (
 
 func New(args []string) {
 	if len(args) < 1 {
-		fmt.Fprintln(os.Stderr, "usage: blog new [post|project] <title>")
+		fmt.Fprintln(os.Stderr, "usage: blog new [post|project|now] <title>")
 		os.Exit(1)
 	}
 
@@ -21,8 +21,10 @@ func New(args []string) {
 		newPost(args[1:])
 	case "project":
 		newProject(args[1:])
+	case "now":
+		newNow()
 	default:
-		fmt.Fprintf(os.Stderr, "unknown type: %s\nusage: blog new [post|project] <title>\n", args[0])
+		fmt.Fprintf(os.Stderr, "unknown type: %s\nusage: blog new [post|project|now] <title>\n", args[0])
 		os.Exit(1)
 	}
 }
@@ -68,6 +70,7 @@ func newProject(args []string) {
 
 	title := args[0]
 	slug := slugify(title)
+	date := time.Now().Format("2006-01-02")
 	dir := filepath.Join("content", "projects")
 	os.MkdirAll(dir, 0o755)
 
@@ -77,11 +80,12 @@ title: %q
 description: ""
 repo: ""
 status: active
+started: %s
 featured: false
 tags: []
 ---
 
-`, title)
+`, title, date)
 
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -92,6 +96,54 @@ tags: []
 	openEditor(path)
 }
 
+var updatedLine = regexp.MustCompile(`(?m)^updated: *(\S+) *$`)
+
+// newNow archives the current /now page as pages/now/<updated>.md and dates
+// now.md today, ready to be edited.
+func newNow() {
+	path := filepath.Join("content", "pages", "now.md")
+	if err := archiveNow(path, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	openEditor(path)
+}
+
+func archiveNow(path string, today time.Time) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m := updatedLine.FindSubmatch(src)
+	if m == nil {
+		return fmt.Errorf("%s has no updated: date to archive it under", path)
+	}
+	date := string(m[1])
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return fmt.Errorf("%s: invalid updated date %q", path, date)
+	}
+	if date == today.Format("2006-01-02") {
+		return fmt.Errorf("%s was already updated today", path)
+	}
+
+	dir := filepath.Join(filepath.Dir(path), "now")
+	os.MkdirAll(dir, 0o755)
+	archived := filepath.Join(dir, date+".md")
+	if _, err := os.Stat(archived); err == nil {
+		return fmt.Errorf("%s already exists", archived)
+	}
+	if err := os.WriteFile(archived, src, 0o644); err != nil {
+		return err
+	}
+
+	src = updatedLine.ReplaceAll(src, []byte("updated: "+today.Format("2006-01-02")))
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("archived %s\n", archived)
+	return nil
+}
+
 var nonAlphaNum = regexp.MustCompile(`[^a-z0-9]+`)
 
 func slugify(s string) string {
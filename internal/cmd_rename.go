@@ -0,0 +1,103 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rename gives a post a new title and slug. The old slug is kept as an
+// alias, so links keep working and the server moves the post's comments
+// over on its next reload.
+func Rename(args []string) {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: blog rename <old-slug> <new-title>")
+		os.Exit(1)
+	}
+
+	dst, err := renamePost("content", args[0], args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("renamed %s → %s\n", args[0], dst)
+}
+
+var (
+	titleLine         = regexp.MustCompile(`(?m)^title:.*$`)
+	inlineAliasesLine = regexp.MustCompile(`(?m)^aliases: *\[(.*)\] *$`)
+	blockAliasesLine  = regexp.MustCompile(`(?m)^aliases: *\n`)
+)
+
+func renamePost(dir, oldSlug, title string) (string, error) {
+	newSlug := slugify(title)
+	if newSlug == "" {
+		return "", fmt.Errorf("title %q has no usable slug", title)
+	}
+
+	var src string
+	for _, sub := range []string{"posts", "private"} {
+		files, _ := filepath.Glob(filepath.Join(dir, sub, "*.md"))
+		for _, f := range files {
+			switch postSlug(filepath.Base(f)) {
+			case oldSlug:
+				src = f
+			case newSlug:
+				return "", fmt.Errorf("a post with slug %q already exists: %s", newSlug, f)
+			}
+		}
+	}
+	if src == "" {
+		return "", fmt.Errorf("no post found with slug %q", oldSlug)
+	}
+	if newSlug == oldSlug {
+		return "", fmt.Errorf("post %q already has that slug", oldSlug)
+	}
+
+	body, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(body, []byte("---\n")) {
+		return "", fmt.Errorf("%s has no frontmatter", src)
+	}
+	end := bytes.Index(body[4:], []byte("\n---"))
+	if end < 0 {
+		return "", fmt.Errorf("%s has unterminated frontmatter", src)
+	}
+	fm, rest := string(body[:4+end+1]), body[4+end+1:]
+
+	fm = titleLine.ReplaceAllLiteralString(fm, fmt.Sprintf("title: %q", title))
+	switch {
+	case inlineAliasesLine.MatchString(fm):
+		fm = inlineAliasesLine.ReplaceAllStringFunc(fm, func(line string) string {
+			list := strings.TrimSpace(inlineAliasesLine.FindStringSubmatch(line)[1])
+			if list == "" {
+				return "aliases: [" + oldSlug + "]"
+			}
+			return "aliases: [" + list + ", " + oldSlug + "]"
+		})
+	case blockAliasesLine.MatchString(fm):
+		fm = blockAliasesLine.ReplaceAllLiteralString(fm, "aliases:\n  - "+oldSlug+"\n")
+	default:
+		fm += "aliases: [" + oldSlug + "]\n"
+	}
+
+	name := newSlug + ".md"
+	if base := filepath.Base(src); postSlug(base) != strings.TrimSuffix(base, ".md") {
+		// Keep the YYYY-MM-DD- prefix
+		name = base[:11] + name
+	}
+	dst := filepath.Join(filepath.Dir(src), name)
+
+	if err := os.WriteFile(dst, append([]byte(fm), rest...), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Remove(src); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
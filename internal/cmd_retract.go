@@ -0,0 +1,66 @@
+package blog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Retract removes a published post and records it in content/retracted.yaml.
+// On its next reload the server answers the post's URL with 410 Gone and
+// hides its comments.
+func Retract(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "usage: blog retract <slug> [reason]")
+		os.Exit(1)
+	}
+
+	reason := strings.Join(args[1:], " ")
+	if err := retractPost("content", args[0], reason, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("retracted %s\n", args[0])
+}
+
+func retractPost(dir, slug, reason string, now time.Time) error {
+	files, _ := filepath.Glob(filepath.Join(dir, "posts", "*.md"))
+	var src string
+	for _, f := range files {
+		if postSlug(filepath.Base(f)) == slug {
+			src = f
+			break
+		}
+	}
+	if src == "" {
+		return fmt.Errorf("no published post found with slug %q", slug)
+	}
+
+	post, err := parsePost(src, newMarkdown())
+	if err != nil {
+		return err
+	}
+
+	list, err := loadRetractions(dir)
+	if err != nil {
+		return fmt.Errorf("retracted.yaml: %w", err)
+	}
+	list = append(list, retraction{
+		Slug:   slug,
+		Title:  post.Title,
+		Date:   now.Format("2006-01-02"),
+		Reason: reason,
+	})
+	out, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "retracted.yaml"), out, 0o644); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,448 @@
+package blog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Fenced code blocks are highlighted by chroma, and take attributes in
+// braces after the language:
+//
+//	```go {title="main.go" hl_lines=[3,5-7] linenos=true}
+//
+// title is shown above the code, hl_lines marks lines the way the line
+// numbers count them, linenos numbers the lines and linenostart sets the
+// first number. Lines of diff blocks are marked as added or removed. Every
+// block carries data-copy, which the script in base.html turns into a copy
+// button.
+//
+// A line {{include "examples/main.go" lines=10-20}} is replaced by those
+// lines of a file under the content directory, when the Markdown has an
+// includeExtension; the line numbers then start at 10.
+//
+// Bad attributes and includes are reported by reload and blog lint.
+
+var kindCodeBlock = ast.NewNodeKind("CodeBlock")
+
+type codeBlockNode struct {
+	ast.BaseBlock
+	Lang      string
+	Code      string
+	Offset    int // in the source, for reporting errors
+	Title     string
+	HLLines   [][2]int
+	LineNos   bool
+	LineStart int // 0 unless set by linenostart or an include
+	Err       string
+}
+
+func (n *codeBlockNode) Kind() ast.NodeKind { return kindCodeBlock }
+
+func (n *codeBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Lang": n.Lang}, nil)
+}
+
+type codeExtension struct{}
+
+func (codeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		// after diagrams, which take their own fenced blocks
+		util.Prioritized(codeTransformer{}, 600),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(codeRenderer{}, 500),
+	))
+}
+
+// codeTransformer replaces fenced code blocks with code nodes carrying
+// their attributes.
+type codeTransformer struct{}
+
+func (codeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var blocks []*ast.FencedCodeBlock
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if b, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			blocks = append(blocks, b)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	source := reader.Source()
+	for _, b := range blocks {
+		n := &codeBlockNode{}
+		var code strings.Builder
+		for i := 0; i < b.Lines().Len(); i++ {
+			seg := b.Lines().At(i)
+			code.Write(seg.Value(source))
+		}
+		n.Code = code.String()
+		if b.Info != nil {
+			n.Offset = b.Info.Segment.Start
+			info := strings.TrimSpace(string(b.Info.Segment.Value(source)))
+			// attributes only follow a language, so ```{r} is a language
+			lang, attrs := info, ""
+			if i := strings.IndexAny(info, " \t{"); i > 0 {
+				lang, attrs = info[:i], strings.TrimSpace(info[i:])
+			} else if i == 0 {
+				lang, _, _ = strings.Cut(info, " ")
+			}
+			n.Lang = lang
+			if strings.HasPrefix(attrs, "{") {
+				if err := n.setAttrs(attrs); err != nil {
+					n.Err = err.Error()
+				}
+			}
+		} else if b.Lines().Len() > 0 {
+			n.Offset = b.Lines().At(0).Start
+		}
+		b.Parent().ReplaceChild(b.Parent(), b, n)
+	}
+}
+
+// setAttrs applies fence attributes like {title="main.go" linenos=true}.
+// hl_lines takes a list, [3,5-7], or the same in quotes.
+func (n *codeBlockNode) setAttrs(s string) error {
+	attrs, err := parseFenceAttrs(s)
+	if err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		switch a.key {
+		case "title":
+			n.Title = a.value
+		case "hl_lines":
+			n.HLLines, err = parseLineRanges(a.value)
+		case "linenos":
+			if n.LineNos, err = strconv.ParseBool(a.value); err != nil {
+				err = fmt.Errorf("%q isn't true or false", a.value)
+			}
+		case "linenostart":
+			if n.LineStart, err = strconv.Atoi(a.value); err != nil || n.LineStart < 1 {
+				err = fmt.Errorf("%q isn't a line number", a.value)
+			}
+		default:
+			return fmt.Errorf("unknown code block attribute %q", a.key)
+		}
+		if err != nil {
+			return fmt.Errorf("code block attribute %s: %v", a.key, err)
+		}
+	}
+	return nil
+}
+
+type fenceAttr struct{ key, value string }
+
+// parseFenceAttrs parses {key=value ...}, where a value is "quoted",
+// [a list] or a bare word. Pairs are separated by spaces or commas.
+func parseFenceAttrs(s string) ([]fenceAttr, error) {
+	s = strings.TrimSpace(s)
+	body, ok := strings.CutSuffix(strings.TrimPrefix(s, "{"), "}")
+	if !ok {
+		return nil, fmt.Errorf("code block attributes %s: missing }", s)
+	}
+	var attrs []fenceAttr
+	for {
+		body = strings.TrimLeft(body, " \t,")
+		if body == "" {
+			return attrs, nil
+		}
+		key, rest, ok := strings.Cut(body, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t,") {
+			return nil, fmt.Errorf("code block attributes %s: expected key=value", s)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+		var value string
+		switch {
+		case strings.HasPrefix(rest, `"`):
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("code block attribute %s: missing closing quote", key)
+			}
+			value, body = rest[1:end+1], rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("code block attribute %s: missing ]", key)
+			}
+			value, body = rest[1:end], rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, " \t,")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, body = rest[:end], rest[end:]
+		}
+		attrs = append(attrs, fenceAttr{key, value})
+	}
+}
+
+// parseLineRanges parses line numbers and ranges like "3,5-7" or "3 5-7".
+func parseLineRanges(s string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(f, "-")
+		a, errA := strconv.Atoi(from)
+		b, errB := a, error(nil)
+		if isRange {
+			b, errB = strconv.Atoi(to)
+		}
+		if errA != nil || errB != nil || a < 1 || b < a {
+			return nil, fmt.Errorf("%q isn't a line or range of lines", f)
+		}
+		ranges = append(ranges, [2]int{a, b})
+	}
+	return ranges, nil
+}
+
+// includeExtension expands {{include}} lines in code blocks with files
+// under dir.
+type includeExtension struct {
+	dir string
+}
+
+func (e includeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		// after codeTransformer has made the code nodes
+		util.Prioritized(includeTransformer{dir: e.dir}, 700),
+	))
+}
+
+var includeDirective = regexp.MustCompile(`^\s*\{\{\s*include\s+"([^"]+)"(?:\s+lines=(\d+)(?:-(\d+))?)?\s*\}\}\s*$`)
+
+type includeTransformer struct {
+	dir string
+}
+
+func (t includeTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var root *os.Root
+	defer func() {
+		if root != nil {
+			root.Close()
+		}
+	}()
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		b, ok := n.(*codeBlockNode)
+		if !ok || !entering || !strings.Contains(b.Code, "{{") {
+			return ast.WalkContinue, nil
+		}
+		lines := strings.SplitAfter(b.Code, "\n")
+		for i, line := range lines {
+			m := includeDirective.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if root == nil {
+				var err error
+				// os.Root keeps includes from reaching outside the content
+				if root, err = os.OpenRoot(t.dir); err != nil {
+					b.Err = fmt.Sprintf("include %q: %v", m[1], err)
+					return ast.WalkContinue, nil
+				}
+			}
+			code, first, err := includeLines(root, m[1], m[2], m[3])
+			if err != nil {
+				if b.Err == "" {
+					b.Err = fmt.Sprintf("include %q: %v", m[1], err)
+				}
+				continue
+			}
+			if strings.TrimSpace(b.Code) == strings.TrimSpace(line) && b.LineStart == 0 {
+				b.LineStart = first
+			}
+			lines[i] = code
+		}
+		b.Code = strings.Join(lines, "")
+		return ast.WalkContinue, nil
+	})
+}
+
+// includeLines returns lines from to to of the file at path in root, or
+// all of it if from is empty, and the number of the first line returned.
+func includeLines(root *os.Root, path, from, to string) (string, int, error) {
+	src, err := root.ReadFile(path)
+	var pathErr *fs.PathError
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", 0, errors.New("file not found")
+	} else if errors.As(err, &pathErr) {
+		return "", 0, pathErr.Err
+	} else if err != nil {
+		return "", 0, err
+	}
+	code := string(src)
+	if code != "" && !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	if from == "" {
+		return code, 1, nil
+	}
+	lines := strings.SplitAfter(code, "\n")
+	lines = lines[:len(lines)-1] // after the final newline
+	a, _ := strconv.Atoi(from)
+	b := a
+	if to != "" {
+		b, _ = strconv.Atoi(to)
+	}
+	if a < 1 || b < a {
+		return "", 0, fmt.Errorf("lines=%s-%s isn't a range of lines", from, to)
+	}
+	if b > len(lines) {
+		return "", 0, fmt.Errorf("lines=%d-%d, but the file has %d", a, b, len(lines))
+	}
+	return strings.Join(lines[a-1:b], ""), a, nil
+}
+
+type codeRenderer struct{}
+
+func (codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindCodeBlock, renderCodeBlock)
+}
+
+func renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*codeBlockNode)
+	w.WriteString(`<div class="code-block`)
+	if n.Err != "" {
+		// the error is for the author, in the reload log and blog lint
+		w.WriteString(" code-error")
+	}
+	w.WriteString(`" data-copy>`)
+	if n.Title != "" {
+		w.WriteString(`<div class="code-title">`)
+		w.Write(util.EscapeHTML([]byte(n.Title)))
+		w.WriteString("</div>")
+	}
+	w.WriteString(highlightCode(n))
+	w.WriteString("</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// highlightCode returns n's code as chroma HTML, or escaped in a plain
+// <pre><code> if chroma doesn't know the language.
+func highlightCode(n *codeBlockNode) string {
+	var lexer chroma.Lexer
+	if n.Lang != "" {
+		lexer = lexers.Get(n.Lang)
+	}
+	var it chroma.Iterator
+	var err error
+	if lexer != nil {
+		it, err = chroma.Coalesce(lexer).Tokenise(nil, n.Code)
+	}
+	if lexer == nil || err != nil {
+		return plainCode(n)
+	}
+
+	opts := []html.Option{html.WithClasses(true)}
+	if n.LineNos {
+		opts = append(opts, html.WithLineNumbers(true))
+	}
+	if n.LineStart > 0 {
+		opts = append(opts, html.BaseLineNumber(n.LineStart))
+	}
+	if len(n.HLLines) > 0 {
+		opts = append(opts, html.HighlightLines(n.HLLines))
+	}
+	tokens := it.Tokens()
+	var buf bytes.Buffer
+	err = html.New(opts...).Format(&buf, styles.Get("github"), chroma.Literator(tokens...))
+	if err != nil {
+		return plainCode(n)
+	}
+	if lexer.Config().Name == "Diff" {
+		return markDiffLines(buf.String(), diffLines(tokens))
+	}
+	return buf.String()
+}
+
+// plainCode returns n's code escaped in a <pre><code>, for languages
+// chroma doesn't know or can't format.
+func plainCode(n *codeBlockNode) string {
+	var b strings.Builder
+	b.WriteString("<pre><code")
+	if n.Lang != "" {
+		b.WriteString(` class="language-`)
+		b.Write(util.EscapeHTML([]byte(n.Lang)))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+	b.Write(util.EscapeHTML([]byte(n.Code)))
+	b.WriteString("</code></pre>")
+	return b.String()
+}
+
+// diffLines returns the class for each line of tokenised diff code:
+// diff-add for inserted lines, diff-del for deleted ones and "" for the
+// rest, including the +++ and --- file headers.
+func diffLines(tokens []chroma.Token) []string {
+	lines := chroma.SplitTokensIntoLines(tokens)
+	classes := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		switch t := line[0]; {
+		case t.Type == chroma.GenericInserted && !strings.HasPrefix(t.Value, "+++"):
+			classes[i] = "diff-add"
+		case t.Type == chroma.GenericDeleted && !strings.HasPrefix(t.Value, "---"):
+			classes[i] = "diff-del"
+		}
+	}
+	return classes
+}
+
+// chromaLine opens each line in chroma's HTML output, with "hl" after
+// it for highlighted lines. TestChromaLineMarkup pins it.
+const chromaLine = `<span class="line`
+
+// markDiffLines adds classes, one per line, to the line spans of
+// highlighted code, so whole lines can be tinted. If the output doesn't
+// have a line span per class it is returned unchanged.
+func markDiffLines(out string, classes []string) string {
+	if strings.Count(out, chromaLine) != len(classes) {
+		return out
+	}
+	var b strings.Builder
+	for _, class := range classes {
+		i := strings.Index(out, chromaLine) + len(chromaLine)
+		b.WriteString(out[:i])
+		out = out[i:]
+		if class != "" {
+			b.WriteString(" " + class)
+		}
+	}
+	b.WriteString(out)
+	return b.String()
+}
+
+// codeIssues reports the code blocks in d with bad attributes or
+// includes.
+func codeIssues(d *lintDoc) []lintIssue {
+	var issues []lintIssue
+	ast.Walk(d.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*codeBlockNode); ok && entering && n.Err != "" {
+			issues = append(issues, lintIssue{File: d.Path, Line: offsetLine(d.Src, n.Offset), Msg: n.Err})
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
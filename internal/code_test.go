@@ -0,0 +1,165 @@
+package blog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodeBlockAttributes(t *testing.T) {
+	out := renderMarkdown(t, "```go {title=\"main.go\" hl_lines=[11,13-14] linenos=true linenostart=10}\n"+
+		"package main\n\nimport \"fmt\"\n\nfunc main() {}\n```\n")
+
+	if !strings.HasPrefix(out, `<div class="code-block" data-copy><div class="code-title">main.go</div><pre class="chroma">`) {
+		t.Errorf("expected a titled code block, got: %s", out)
+	}
+	if !strings.Contains(out, `<span class="line"><span class="ln">10</span>`) {
+		t.Errorf("line numbers should start at 10: %s", out)
+	}
+	// hl_lines counts like the line numbers, from 10
+	for _, ln := range []string{"11", "13", "14"} {
+		if !strings.Contains(out, `<span class="line hl"><span class="ln">`+ln+`</span>`) {
+			t.Errorf("line %s should be highlighted: %s", ln, out)
+		}
+	}
+	if strings.Count(out, `class="line hl"`) != 3 {
+		t.Errorf("expected three highlighted lines: %s", out)
+	}
+}
+
+func TestCodeBlockPlain(t *testing.T) {
+	out := renderMarkdown(t, "```\n<b>\n```\n\n```nosuchlang\nx\n```\n")
+	want := `<div class="code-block" data-copy><pre><code>&lt;b&gt;` + "\n" + `</code></pre></div>` + "\n" +
+		`<div class="code-block" data-copy><pre><code class="language-nosuchlang">x` + "\n" + `</code></pre></div>` + "\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestCodeBlockDiff(t *testing.T) {
+	out := renderMarkdown(t, "```diff\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n same\n```\n")
+	for _, want := range []string{
+		`<span class="line"><span class="cl"><span class="gd">--- a/main.go`,
+		`<span class="line"><span class="cl"><span class="gi">+++ b/main.go`,
+		`<span class="line diff-del"><span class="cl"><span class="gd">-old`,
+		`<span class="line diff-add"><span class="cl"><span class="gi">+new`,
+		`<span class="line"><span class="cl"> same`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %s", want, out)
+		}
+	}
+}
+
+// TestChromaLineMarkup pins the chroma line spans markDiffLines adds
+// its classes to.
+func TestChromaLineMarkup(t *testing.T) {
+	n := &codeBlockNode{Lang: "diff", Code: "-old\n+new\n", LineNos: true, HLLines: [][2]int{{2, 2}}}
+	out := highlightCode(n)
+	if got := strings.Count(out, chromaLine); got != 2 {
+		t.Errorf("got %d line spans, want 2 in %s", got, out)
+	}
+	for _, want := range []string{
+		`<span class="line diff-del"><span class="ln">1</span><span class="cl"><span class="gd">-old`,
+		`<span class="line diff-add hl"><span class="ln">2</span><span class="cl"><span class="gi">+new`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %s", want, out)
+		}
+	}
+	if got := markDiffLines(out, []string{"diff-add"}); got != out {
+		t.Errorf("markDiffLines changed output with the wrong line count:\n%s", got)
+	}
+}
+
+func TestCodeBlockBadAttributes(t *testing.T) {
+	for _, tt := range []struct{ info, msg string }{
+		{`go {colour=red}`, `unknown code block attribute "colour"`},
+		{`go {hl_lines=[3-1]}`, `code block attribute hl_lines: "3-1" isn't a line or range of lines`},
+		{`go {linenos=yes}`, `code block attribute linenos: "yes" isn't true or false`},
+		{`go {title="main.go}`, `code block attribute title: missing closing quote`},
+		{`go {title}`, `expected key=value`},
+	} {
+		out := renderMarkdown(t, "```"+tt.info+"\nx := 1\n```\n")
+		if !strings.HasPrefix(out, `<div class="code-block code-error" data-copy>`) {
+			t.Errorf("%s: expected the code-error class and no message, got: %s", tt.info, out)
+		}
+		if !strings.Contains(out, `<span class="nx">x</span>`) {
+			t.Errorf("%s: the code should still be highlighted: %s", tt.info, out)
+		}
+
+		dir := writeContent(t, map[string]string{
+			"posts/2026-01-01-code.md": "---\ntitle: Code\ndate: 2026-01-01\n---\n```" + tt.info + "\nx := 1\n```\n",
+		})
+		issues := postRenderIssues(t, dir, newMarkdown())
+		if len(issues) != 1 || !strings.Contains(issues[0].Msg, tt.msg) {
+			t.Errorf("%s: issues = %v, want %q", tt.info, issues, tt.msg)
+		}
+	}
+}
+
+func TestCodeBlockBracedLanguage(t *testing.T) {
+	out := renderMarkdown(t, "```{r}\nx <- 1\n```\n\n```go{title=\"main.go\"}\nx := 1\n```\n")
+	if !strings.Contains(out, `<div class="code-block" data-copy><pre><code class="language-{r}">x &lt;- 1`) {
+		t.Errorf("{r} should be the language, not attributes: %s", out)
+	}
+	if !strings.Contains(out, `<div class="code-title">main.go</div>`) {
+		t.Errorf("attributes straight after the language should still apply: %s", out)
+	}
+}
+
+func TestCodeInclude(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"examples/main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}",
+		"posts/2026-01-01-include.md": "---\ntitle: Include\ndate: 2026-01-01\n---\n" +
+			"```go\n{{include \"examples/missing.go\"}}\n```\n\n" +
+			"```go\n{{include \"examples/main.go\" lines=6-9}}\n```\n\n" +
+			"```go\n{{include \"../secret.go\"}}\n```\n",
+	})
+	md := newMarkdown(includeExtension{dir: dir})
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte("```go {linenos=true hl_lines=[6]}\n{{include \"examples/main.go\" lines=5-7}}\n```\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `<span class="line"><span class="ln">5</span><span class="cl"><span class="kd">func</span>`) {
+		t.Errorf("included lines should be numbered from 5: %s", out)
+	}
+	if !strings.Contains(out, `<span class="line hl"><span class="ln">6</span>`) || strings.Contains(out, "package") {
+		t.Errorf("expected only lines 5-7 with line 6 highlighted: %s", out)
+	}
+
+	buf.Reset()
+	if err := md.Convert([]byte("```go\n// before\n{{ include \"examples/main.go\" lines=1 }}\n// after\n```\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "before") || !strings.Contains(out, `<span class="kn">package</span>`) || !strings.Contains(out, "after") || strings.Contains(out, "<span class=\"ln\">") {
+		t.Errorf("include should sit between the other lines: %s", out)
+	}
+
+	issues := postRenderIssues(t, dir, md)
+	want := []struct {
+		line int
+		msg  string
+	}{
+		{5, `include "examples/missing.go": file not found`},
+		{9, `include "examples/main.go": lines=6-9, but the file has 7`},
+		{13, `include "../secret.go": path escapes from parent`},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v, want %d", issues, len(want))
+	}
+	for i, w := range want {
+		if issues[i].Line != w.line || issues[i].Msg != w.msg {
+			t.Errorf("issue %d = %v, want line %d: %s", i, issues[i], w.line, w.msg)
+		}
+	}
+}
+
+func TestCodeIncludeNeedsExtension(t *testing.T) {
+	out := renderMarkdown(t, "```\n{{include \"examples/main.go\"}}\n```\n")
+	if !strings.Contains(out, `{{include &quot;examples/main.go&quot;}}`) {
+		t.Errorf("without includeExtension the directive should stay as written: %s", out)
+	}
+}
@@ -126,6 +126,16 @@ func newRateLimiter() *rateLimiter {
 	}
 }
 
+// newSuggestLimiter is looser than newRateLimiter since typeahead fires on
+// every keystroke, but still stops a single client from hammering SQLite.
+func newSuggestLimiter() *rateLimiter {
+	return &rateLimiter{
+		requests: make(map[string][]time.Time),
+		limit:    120,
+		window:   time.Minute,
+	}
+}
+
 func (rl *rateLimiter) allow(key string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
@@ -10,6 +10,7 @@ type Config struct {
 	BaseURL             string
 	ContentDir          string
 	DBPath              string
+	ImageCacheDir       string
 	AdminAPIKey         string
 	BlogURL             string
 	SMTPHost            string
@@ -18,6 +19,8 @@ type Config struct {
 	SMTPPassword        string
 	FromEmail           string
 	DeployWebhookSecret string
+	StrictFrontmatter   bool
+	RevisionHistory     bool
 }
 
 func LoadConfig() Config {
@@ -26,6 +29,7 @@ func LoadConfig() Config {
 		BaseURL:             envOr("BASE_URL", "http://localhost:8080"),
 		ContentDir:          envOr("CONTENT_DIR", "content"),
 		DBPath:              envOr("DB_PATH", "blog.db"),
+		ImageCacheDir:       envOr("IMAGE_CACHE_DIR", "cache/images"),
 		AdminAPIKey:         os.Getenv("ADMIN_API_KEY"),
 		BlogURL:             os.Getenv("BLOG_URL"),
 		SMTPHost:            os.Getenv("SMTP_HOST"),
@@ -34,6 +38,8 @@ func LoadConfig() Config {
 		SMTPPassword:        os.Getenv("SMTP_PASSWORD"),
 		FromEmail:           os.Getenv("FROM_EMAIL"),
 		DeployWebhookSecret: os.Getenv("DEPLOY_WEBHOOK_SECRET"),
+		StrictFrontmatter:   os.Getenv("STRICT_FRONTMATTER") == "true",
+		RevisionHistory:     os.Getenv("REVISION_HISTORY") == "true",
 	}
 }
 
@@ -0,0 +1,97 @@
+package blog
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Containers are blocks fenced by ":::name" and ":::" holding ordinary
+// markdown. The name picks what they render as: a gallery, or a callout
+// with an optional title after the name.
+//
+//	:::warning Breaking change
+//	The config format changed in v2.
+//	:::
+
+// fence records that a container's closing ::: has been seen, so an
+// outer container doesn't take a nested one's closing line as its own.
+type fence struct{ closed bool }
+
+func (f *fence) close() { f.closed = true }
+
+func (f *fence) isClosed() bool { return f.closed }
+
+type fencedNode interface {
+	ast.Node
+	close()
+	isClosed() bool
+}
+
+func newContainer(name, title string) fencedNode {
+	if name == "gallery" {
+		return &galleryNode{}
+	}
+	if _, ok := calloutTitles[name]; ok {
+		return &calloutNode{Callout: name, Title: title}
+	}
+	return nil
+}
+
+type containerExtension struct{}
+
+func (containerExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(
+		util.Prioritized(containerParser{}, 750),
+	))
+}
+
+type containerParser struct{}
+
+func (containerParser) Trigger() []byte { return []byte{':'} }
+
+func (containerParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	rest, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte(":::"))
+	if !ok {
+		return nil, parser.NoChildren
+	}
+	name, title, _ := strings.Cut(string(rest), " ")
+	node := newContainer(name, strings.TrimSpace(title))
+	if node == nil {
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - trailingNewline(line))
+	return node, parser.HasChildren
+}
+
+func (containerParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	if inner, ok := node.LastChild().(fencedNode); ok && !inner.isClosed() {
+		return parser.Continue | parser.HasChildren
+	}
+	line, segment := reader.PeekLine()
+	if string(bytes.TrimSpace(line)) == ":::" {
+		reader.Advance(segment.Len() - trailingNewline(line))
+		node.(fencedNode).close()
+		return parser.Close
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (containerParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (containerParser) CanInterruptParagraph() bool { return true }
+
+func (containerParser) CanAcceptIndentedLine() bool { return false }
+
+func trailingNewline(line []byte) int {
+	if len(line) > 0 && line[len(line)-1] == '\n' {
+		return 1
+	}
+	return 0
+}
@@ -3,7 +3,9 @@ package blog
 import (
 	"bytes"
 	"fmt"
+	stdhtml "html"
 	"html/template"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,10 +16,11 @@ import (
 	"github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/yuin/goldmark"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
 	goldhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
 	"go.abhg.dev/goldmark/anchor"
 	"go.abhg.dev/goldmark/frontmatter"
 )
@@ -27,39 +30,79 @@ type Post struct {
 	Slug        string
 	Description string
 	Project     string
+	Series      string
+	SeriesSlug  string
+	SeriesOrder int
 	Private     bool
 	Date        time.Time
+	Updated     time.Time // zero unless edited after Date
+	Path        string    // source file, for git history
+	Image       string    // image: override for the generated social card
 	Tags        []string
+	Aliases     []string
 	ReadTime    time.Duration
+	TOC         []TOCEntry
+	Extra       map[string]any
 	Body        template.HTML
+	issues      contentIssues
+}
+
+// TOCEntry is a heading in a post's table of contents. Children holds
+// deeper headings nested under it.
+type TOCEntry struct {
+	ID       string
+	Title    string
+	Children []TOCEntry
 }
 
 type Page struct {
-	Title string
-	Slug  string
-	Body  template.HTML
+	Title    string
+	Slug     string
+	Nav      bool
+	NavTitle string
+	NavOrder int
+	Updated  time.Time
+	Aliases  []string
+	Extra    map[string]any
+	Body     template.HTML
+	issues   contentIssues
 }
 
 type postFrontmatter struct {
-	Title       string   `yaml:"title"`
-	Date        string   `yaml:"date"`
-	Tags        []string `yaml:"tags"`
-	Description string   `yaml:"description"`
-	Project     string   `yaml:"project"`
+	Title       string         `yaml:"title"`
+	Date        string         `yaml:"date"`
+	Tags        []string       `yaml:"tags"`
+	Description string         `yaml:"description"`
+	Project     string         `yaml:"project"`
+	Series      string         `yaml:"series"`
+	SeriesOrder int            `yaml:"series_order"`
+	Updated     string         `yaml:"updated"`
+	TOC         *bool          `yaml:"toc"`
+	Aliases     []string       `yaml:"aliases"`
+	Image       string         `yaml:"image"`
+	Extra       map[string]any `yaml:"extra"`
 }
 
-func newMarkdown() goldmark.Markdown {
+// tocMinHeadings is how many h2–h4 headings a post needs before it gets a
+// table of contents without an explicit toc: true.
+const tocMinHeadings = 4
+
+func newMarkdown(exts ...goldmark.Extender) goldmark.Markdown {
 	return goldmark.New(
-		goldmark.WithExtensions(
+		goldmark.WithExtensions(append([]goldmark.Extender{
 			&frontmatter.Extender{},
 			extension.Typographer,
 			extension.Table,
 			extension.Strikethrough,
-			highlighting.NewHighlighting(
-				highlighting.WithFormatOptions(html.WithClasses(true)),
-			),
 			&anchor.Extender{Texter: anchor.Text("#")},
-		),
+			containerExtension{},
+			figureExtension{},
+			calloutExtension{},
+			footnoteExtension{},
+			mathExtension{},
+			newDiagramExtension(),
+			codeExtension{},
+		}, exts...)...),
 		goldmark.WithParserOptions(
 			parser.WithAutoHeadingID(),
 		),
@@ -90,7 +133,9 @@ func generateChromaCSS() (string, error) {
 	return buf.String(), nil
 }
 
-func loadAllPosts(dir string, md goldmark.Markdown) ([]Post, error) {
+// loadAllPosts loads the public and private posts, newest first, and the
+// problems found in them, including the posts it had to skip.
+func loadAllPosts(dir string, md goldmark.Markdown) ([]Post, contentIssues, error) {
 	dirs := []struct {
 		path    string
 		private bool
@@ -100,13 +145,15 @@ func loadAllPosts(dir string, md goldmark.Markdown) ([]Post, error) {
 	}
 
 	var posts []Post
+	var issues contentIssues
 	for _, d := range dirs {
 		files, err := filepath.Glob(filepath.Join(d.path, "*.md"))
 		if err != nil {
-			return nil, err
+			return nil, contentIssues{}, err
 		}
 		for _, f := range files {
 			p, err := parsePost(f, md)
+			issues.add(p.issues)
 			if err != nil {
 				// Skip unparseable files (e.g. git-crypt binary blobs)
 				log.Printf("skipping %s: %v", f, err)
@@ -120,9 +167,11 @@ func loadAllPosts(dir string, md goldmark.Markdown) ([]Post, error) {
 	sort.Slice(posts, func(i, j int) bool {
 		return posts[i].Date.After(posts[j].Date)
 	})
-	return posts, nil
+	return posts, issues, nil
 }
 
+// parsePost parses a post. When its frontmatter doesn't decode, the
+// returned post is empty apart from the issues saying why.
 func parsePost(path string, md goldmark.Markdown) (Post, error) {
 	src, err := os.ReadFile(path)
 	if err != nil {
@@ -130,35 +179,137 @@ func parsePost(path string, md goldmark.Markdown) (Post, error) {
 	}
 
 	ctx := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
 	var buf bytes.Buffer
-	if err := md.Convert(src, &buf, parser.WithContext(ctx)); err != nil {
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
 		return Post{}, err
 	}
 
+	issues := checkDoc(path, "post", src, doc, ctx)
 	var meta postFrontmatter
 	fm := frontmatter.Get(ctx)
 	if fm != nil {
 		if err := fm.Decode(&meta); err != nil {
-			return Post{}, fmt.Errorf("frontmatter: %w", err)
+			return Post{issues: issues}, fmt.Errorf("frontmatter: %w", err)
 		}
 	}
 
 	date, _ := time.Parse("2006-01-02", meta.Date)
+	updated, _ := time.Parse("2006-01-02", meta.Updated)
 	slug := postSlug(filepath.Base(path))
 	readTime := estimatedReadTime(src)
 
+	toc := buildTOC(doc, src)
+	showTOC := countTOC(toc) >= tocMinHeadings
+	if meta.TOC != nil {
+		showTOC = *meta.TOC
+	}
+	if !showTOC {
+		toc = nil
+	}
+
 	return Post{
 		Title:       meta.Title,
 		Slug:        slug,
 		Description: meta.Description,
 		Project:     meta.Project,
+		Series:      meta.Series,
+		SeriesSlug:  slugify(meta.Series),
+		SeriesOrder: meta.SeriesOrder,
 		Date:        date,
-		Tags:        meta.Tags,
+		Updated:     updated,
+		Path:        path,
+		Image:       meta.Image,
+		Tags:        normalizeTags(meta.Tags),
+		Aliases:     meta.Aliases,
 		ReadTime:    readTime,
+		TOC:         toc,
+		Extra:       meta.Extra,
 		Body:        template.HTML(buf.String()),
+		issues:      issues,
 	}, nil
 }
 
+// buildTOC collects h2–h4 headings into a nested table of contents. A heading
+// that skips a level (h2 → h4) nests under the closest shallower heading.
+func buildTOC(doc ast.Node, src []byte) []TOCEntry {
+	type heading struct {
+		level int
+		entry TOCEntry
+	}
+	var flat []heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		h, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		if h.Level < 2 || h.Level > 4 {
+			return ast.WalkSkipChildren, nil
+		}
+		id, _ := h.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		if len(idBytes) == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		flat = append(flat, heading{h.Level, TOCEntry{
+			ID:    string(idBytes),
+			Title: headingText(h, src),
+		}})
+		return ast.WalkSkipChildren, nil
+	})
+
+	// nest attaches headings deeper than level to the entry before them
+	var nest func(i, level int) ([]TOCEntry, int)
+	nest = func(i, level int) ([]TOCEntry, int) {
+		var out []TOCEntry
+		for i < len(flat) && flat[i].level >= level {
+			e := flat[i].entry
+			lvl := flat[i].level
+			i++
+			e.Children, i = nest(i, lvl+1)
+			out = append(out, e)
+		}
+		return out, i
+	}
+	toc, _ := nest(0, 2)
+	return toc
+}
+
+// headingText returns a heading's plain text, skipping the "#" anchor link.
+func headingText(h *ast.Heading, src []byte) string {
+	var b strings.Builder
+	ast.Walk(h, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *anchor.Node:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			b.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			// Typographer substitutions are stored as HTML entities
+			b.WriteString(stdhtml.UnescapeString(string(n.Value)))
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+func countTOC(toc []TOCEntry) int {
+	n := len(toc)
+	for _, e := range toc {
+		n += countTOC(e.Children)
+	}
+	return n
+}
+
 // postSlug derives slug from filename: "2026-02-25-hello-world.md" → "hello-world"
 func postSlug(filename string) string {
 	name := strings.TrimSuffix(filename, ".md")
@@ -176,78 +327,206 @@ func estimatedReadTime(body []byte) time.Duration {
 	return time.Duration(minutes * float64(time.Minute))
 }
 
-func loadAllPages(dir string, md goldmark.Markdown) ([]Page, error) {
-	pattern := filepath.Join(dir, "pages", "*.md")
-	files, err := filepath.Glob(pattern)
+// loadAllPages loads content/pages recursively. Nested files are served
+// under their path: pages/talks/2026.md is /talks/2026, and
+// pages/talks/index.md is /talks. Archived /now pages are loaded separately
+// by loadNowHistory.
+func loadAllPages(dir string, md goldmark.Markdown) ([]Page, contentIssues, error) {
+	files, err := pageFiles(filepath.Join(dir, "pages"))
 	if err != nil {
-		return nil, err
+		return nil, contentIssues{}, err
 	}
 
 	var pages []Page
+	var issues contentIssues
 	for _, f := range files {
-		p, err := parsePage(f, md)
+		slug := pageSlug(filepath.Join(dir, "pages"), f)
+		if _, ok := nowSnapshotSlug(slug); ok {
+			// Served from app.nowHistory at /now/{date}
+			continue
+		}
+		p, err := parsePage(f, "page", md)
 		if err != nil {
-			return nil, fmt.Errorf("parsing %s: %w", f, err)
+			return nil, contentIssues{}, fmt.Errorf("parsing %s: %w", f, err)
 		}
+		p.Slug = slug
+		issues.add(p.issues)
 		pages = append(pages, p)
 	}
-	return pages, nil
+	return pages, issues, nil
 }
 
-func parsePage(path string, md goldmark.Markdown) (Page, error) {
+// pageFiles lists the markdown files under root, in lexical order. A missing
+// root is not an error.
+func pageFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".md") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// pageSlug is the URL path of a page file relative to root, without the
+// leading slash.
+func pageSlug(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		rel = filepath.Base(path)
+	}
+	slug := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+	return strings.TrimSuffix(slug, "/index")
+}
+
+// parsePage parses a page. kind is page or project-page, for the
+// frontmatter schema. Like parsePost, it returns the issues with a
+// frontmatter error.
+func parsePage(path, kind string, md goldmark.Markdown) (Page, error) {
 	src, err := os.ReadFile(path)
 	if err != nil {
 		return Page{}, err
 	}
 
 	ctx := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
 	var buf bytes.Buffer
-	if err := md.Convert(src, &buf, parser.WithContext(ctx)); err != nil {
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
 		return Page{}, err
 	}
 
 	var meta struct {
-		Title string `yaml:"title"`
+		Title    string         `yaml:"title"`
+		Nav      bool           `yaml:"nav"`
+		NavTitle string         `yaml:"nav_title"`
+		NavOrder int            `yaml:"nav_order"`
+		Updated  string         `yaml:"updated"`
+		Aliases  []string       `yaml:"aliases"`
+		Extra    map[string]any `yaml:"extra"`
 	}
+	issues := checkDoc(path, kind, src, doc, ctx)
 	fm := frontmatter.Get(ctx)
 	if fm != nil {
 		if err := fm.Decode(&meta); err != nil {
-			return Page{}, fmt.Errorf("frontmatter: %w", err)
+			return Page{issues: issues}, fmt.Errorf("frontmatter: %w", err)
 		}
 	}
 
 	slug := strings.TrimSuffix(filepath.Base(path), ".md")
 
+	// like posts, a bad date is left zero; the frontmatter check reports it
+	updated, _ := time.Parse("2006-01-02", meta.Updated)
+
 	return Page{
-		Title: meta.Title,
-		Slug:  slug,
-		Body:  template.HTML(buf.String()),
+		Title:    meta.Title,
+		Slug:     slug,
+		Nav:      meta.Nav,
+		NavTitle: meta.NavTitle,
+		NavOrder: meta.NavOrder,
+		Updated:  updated,
+		Aliases:  meta.Aliases,
+		Extra:    meta.Extra,
+		Body:     template.HTML(buf.String()),
+		issues:   issues,
 	}, nil
 }
 
 func (app *App) reload() error {
-	posts, err := loadAllPosts(app.cfg.ContentDir, app.md)
+	// images first: the markdown renderer reads their sizes
+	images, err := processImages(app.cfg.ContentDir, app.cfg.ImageCacheDir)
+	if err != nil {
+		log.Printf("warning: images: %v", err)
+	} else {
+		app.images.set(images)
+	}
+
+	// the loaders check each file as they parse it
+	var issues contentIssues
+	posts, found, err := loadAllPosts(app.cfg.ContentDir, app.md)
+	if err != nil {
+		return err
+	}
+	issues.add(found)
+	applyGitDates(app.cfg.ContentDir, posts)
+	pages, found, err := loadAllPages(app.cfg.ContentDir, app.md)
 	if err != nil {
 		return err
 	}
-	pages, err := loadAllPages(app.cfg.ContentDir, app.md)
+	issues.add(found)
+	projects, found, err := loadAllProjects(app.cfg.ContentDir, app.md)
 	if err != nil {
 		return err
 	}
-	projects, err := loadAllProjects(app.cfg.ContentDir, app.md)
+	issues.add(found)
+	tags, found, err := loadAllTags(app.cfg.ContentDir, app.md)
 	if err != nil {
 		return err
 	}
+	issues.add(found)
+	nowHistory, found, err := loadNowHistory(app.cfg.ContentDir, app.md)
+	if err != nil {
+		return err
+	}
+	issues.add(found)
+	rules, err := loadRedirects(app.cfg.ContentDir)
+	if err != nil {
+		return fmt.Errorf("redirects: %w", err)
+	}
+	retracted, err := loadRetractions(app.cfg.ContentDir)
+	if err != nil {
+		return fmt.Errorf("retracted.yaml: %w", err)
+	}
+
+	if app.cfg.StrictFrontmatter && len(issues.Frontmatter) > 0 {
+		msgs := make([]string, len(issues.Frontmatter))
+		for i, issue := range issues.Frontmatter {
+			msgs[i] = issue.String()
+		}
+		return fmt.Errorf("frontmatter (strict mode):\n%s", strings.Join(msgs, "\n"))
+	}
+	for _, issue := range append(issues.Frontmatter, issues.Render...) {
+		log.Printf("warning: %s", issue)
+	}
+
+	pub := publicPosts(posts)
+	visible := pub
+	if app.cfg.isLocal() {
+		visible = posts
+	}
+	links := buildPostLinks(visible)
+	redirects := buildRedirects(visible, pages, projects, rules)
+
+	app.mu.RLock()
+	prevOG := app.ogImages
+	app.mu.RUnlock()
+	ogImages := buildOGImages(visible, projects, prevOG)
 
 	app.mu.Lock()
 	app.posts = posts
 	app.pages = pages
+	app.nav = buildNav(pages)
+	app.nowHistory = nowHistory
+	app.redirects = redirects
+	app.revisions = make(map[string][]revision)
+	app.ogImages = ogImages
 	app.projects = projects
+	app.tags = tags
+	app.postLinks = links
 	app.mu.Unlock()
 
-	pub := publicPosts(posts)
-
 	if app.db != nil {
+		migrateAliasedSlugs(app.db, posts)
+		gone := trackPublished(app.db, pub, retracted)
+		app.mu.Lock()
+		app.gone = gone
+		app.mu.Unlock()
 		rebuildSearchIndex(app.db, pub, projects)
 	}
 
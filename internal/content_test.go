@@ -39,6 +39,8 @@ date: 2026-02-25
 tags: [go, web]
 description: A test post
 project: blog
+series: Tori Build Log
+series_order: 2
 ---
 
 Hello **world**.
@@ -64,6 +66,9 @@ Hello **world**.
 	if post.Project != "blog" {
 		t.Errorf("Project = %q, want %q", post.Project, "blog")
 	}
+	if post.SeriesSlug != "tori-build-log" || post.SeriesOrder != 2 {
+		t.Errorf("series = %q/%d, want tori-build-log/2", post.SeriesSlug, post.SeriesOrder)
+	}
 	if len(post.Tags) != 2 || post.Tags[0] != "go" || post.Tags[1] != "web" {
 		t.Errorf("Tags = %v, want [go web]", post.Tags)
 	}
@@ -102,7 +107,7 @@ Secret content.
 `), 0644)
 
 	md := newMarkdown()
-	posts, err := loadAllPosts(dir, md)
+	posts, _, err := loadAllPosts(dir, md)
 	if err != nil {
 		t.Fatalf("loadAllPosts: %v", err)
 	}
@@ -151,7 +156,7 @@ Content.
 	t.Cleanup(func() { os.Chmod(path, 0644) })
 
 	md := newMarkdown()
-	posts, err := loadAllPosts(dir, md)
+	posts, _, err := loadAllPosts(dir, md)
 	if err != nil {
 		t.Fatalf("loadAllPosts: %v", err)
 	}
@@ -176,7 +181,7 @@ My **tools**.
 	os.WriteFile(path, []byte(content), 0644)
 
 	md := newMarkdown()
-	page, err := parsePage(path, md)
+	page, err := parsePage(path, "page", md)
 	if err != nil {
 		t.Fatalf("parsePage: %v", err)
 	}
@@ -192,6 +197,20 @@ My **tools**.
 	}
 }
 
+func TestParsePageBadUpdated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "now.md")
+	os.WriteFile(path, []byte("---\ntitle: Now\nupdated: last week\n---\nHi.\n"), 0644)
+
+	page, err := parsePage(path, "page", newMarkdown())
+	if err != nil {
+		t.Fatalf("a bad updated date shouldn't fail the page: %v", err)
+	}
+	if !page.Updated.IsZero() {
+		t.Errorf("Updated = %v, want zero", page.Updated)
+	}
+}
+
 func TestEstimatedReadTime(t *testing.T) {
 	tests := []struct {
 		name string
@@ -221,6 +240,12 @@ func TestParseProject(t *testing.T) {
 title: My Project
 description: A cool project
 repo: https://github.com/user/repo
+status: maintained
+order: 2
+started: 2025-06-01
+links:
+  docs: https://docs.example.com
+cover: /images/cover.png
 featured: true
 tags: [go, cli]
 ---
@@ -248,7 +273,93 @@ Project **description**.
 	if project.Repo != "https://github.com/user/repo" {
 		t.Errorf("Repo = %q, want github URL", project.Repo)
 	}
+	if project.Status != "maintained" || project.Order != 2 {
+		t.Errorf("Status/Order = %q/%d, want maintained/2", project.Status, project.Order)
+	}
+	if !project.Started.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) || !project.Ended.IsZero() {
+		t.Errorf("Started/Ended = %v/%v, want 2025-06-01/zero", project.Started, project.Ended)
+	}
+	if project.Links.Docs != "https://docs.example.com" || project.Cover != "/images/cover.png" {
+		t.Errorf("Links/Cover = %+v/%q", project.Links, project.Cover)
+	}
 	if !strings.Contains(string(project.Body), "<strong>description</strong>") {
 		t.Errorf("Body should contain rendered markdown, got %q", project.Body)
 	}
 }
+
+func TestParsePostTOC(t *testing.T) {
+	body := `
+## Background
+
+### Why "simple"
+
+## Building it
+
+#### Skipped a level
+
+## Wrapping up
+`
+	tests := []struct {
+		name    string
+		toc     string
+		wantTOC bool
+	}{
+		{"auto on", "", true},
+		{"forced off", "toc: false\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "2026-03-15-toc.md")
+			os.WriteFile(path, []byte("---\ntitle: TOC\n"+tt.toc+"---\n"+body), 0644)
+
+			post, err := parsePost(path, newMarkdown())
+			if err != nil {
+				t.Fatalf("parsePost: %v", err)
+			}
+			if !tt.wantTOC {
+				if post.TOC != nil {
+					t.Errorf("TOC = %v, want nil", post.TOC)
+				}
+				return
+			}
+
+			if len(post.TOC) != 3 {
+				t.Fatalf("got %d top-level entries, want 3: %+v", len(post.TOC), post.TOC)
+			}
+			if post.TOC[0].ID != "background" || post.TOC[0].Title != "Background" {
+				t.Errorf("TOC[0] = %+v, want background/Background", post.TOC[0])
+			}
+			if len(post.TOC[0].Children) != 1 || post.TOC[0].Children[0].Title != "Why “simple”" {
+				t.Errorf("TOC[0].Children = %+v, want one typographer-quoted child", post.TOC[0].Children)
+			}
+			if len(post.TOC[1].Children) != 1 || post.TOC[1].Children[0].ID != "skipped-a-level" {
+				t.Errorf("TOC[1].Children = %+v, want skipped-a-level", post.TOC[1].Children)
+			}
+		})
+	}
+}
+
+func TestParsePostTOCShortPost(t *testing.T) {
+	dir := t.TempDir()
+	src := "---\ntitle: Short\n---\n## Only one\n"
+	path := filepath.Join(dir, "short.md")
+	os.WriteFile(path, []byte(src), 0644)
+
+	post, err := parsePost(path, newMarkdown())
+	if err != nil {
+		t.Fatalf("parsePost: %v", err)
+	}
+	if post.TOC != nil {
+		t.Errorf("short post TOC = %v, want nil", post.TOC)
+	}
+
+	os.WriteFile(path, []byte("---\ntitle: Short\ntoc: true\n---\n## Only one\n"), 0644)
+	post, err = parsePost(path, newMarkdown())
+	if err != nil {
+		t.Fatalf("parsePost: %v", err)
+	}
+	if len(post.TOC) != 1 || post.TOC[0].ID != "only-one" {
+		t.Errorf("toc: true TOC = %+v, want [only-one]", post.TOC)
+	}
+}
@@ -61,6 +61,24 @@ func createTables(db *sql.DB) error {
 			notified_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 		);
 
+		CREATE TABLE IF NOT EXISTS slug_migrations (
+			old_slug    TEXT NOT NULL,
+			new_slug    TEXT NOT NULL,
+			migrated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (old_slug, new_slug)
+		);
+
+		CREATE TABLE IF NOT EXISTS published_posts (
+			slug       TEXT PRIMARY KEY,
+			title      TEXT NOT NULL,
+			first_seen DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS retracted_posts (
+			slug      TEXT PRIMARY KEY,
+			hidden_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+
 		CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
 			slug, title, tags, body, content_type
 		);
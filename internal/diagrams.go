@@ -0,0 +1,407 @@
+package blog
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Fenced code blocks tagged dot, pikchr or sequence are drawn as inline
+// SVG while parsing, by the layouts in dot.go, pikchr.go and sequence.go.
+// They use currentColor and the page font, so they follow dark mode and
+// read in feeds without CSS. Rendered diagrams are cached by a hash of
+// their source, so a reload only lays out the ones that changed.
+//
+// A diagram that doesn't parse, is too big, or takes too long to lay out
+// is shown as its source with the diagram-error class, and reported by
+// reload and blog lint.
+
+var kindDiagram = ast.NewNodeKind("Diagram")
+
+// diagramLayouts turn a diagram's source into SVG, by fence language. They
+// give up with ctx's error once it's done.
+var diagramLayouts = map[string]func(ctx context.Context, src string) (string, error){
+	"dot":      layoutDot,
+	"pikchr":   layoutPikchr,
+	"sequence": layoutSequence,
+}
+
+type diagramNode struct {
+	ast.BaseBlock
+	Lang   string
+	Source string
+	Offset int // in the source, for reporting errors
+	SVG    string
+	Err    string
+}
+
+func (n *diagramNode) Kind() ast.NodeKind { return kindDiagram }
+
+func (n *diagramNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Lang": n.Lang}, nil)
+}
+
+type diagramExtension struct {
+	cache *diagramCache
+}
+
+func newDiagramExtension() diagramExtension {
+	return diagramExtension{cache: &diagramCache{
+		order: list.New(),
+		items: make(map[[32]byte]*list.Element),
+	}}
+}
+
+func (e diagramExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(diagramTransformer{cache: e.cache}, 500),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(diagramRenderer{}, 500),
+	))
+}
+
+type diagram struct {
+	svg string
+	err string
+}
+
+// diagramCache keeps the diagramCacheSize most recently used diagrams by
+// a hash of their language and source.
+type diagramCache struct {
+	mu    sync.Mutex
+	order *list.List // of *cachedDiagram, most recently used first
+	items map[[32]byte]*list.Element
+}
+
+type cachedDiagram struct {
+	key [32]byte
+	diagram
+}
+
+const diagramCacheSize = 1000
+
+func (c *diagramCache) render(lang, src string) diagram {
+	key := sha256.Sum256([]byte(lang + "\x00" + src))
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cachedDiagram).diagram
+	}
+	c.mu.Unlock()
+
+	var d diagram
+	svg, err := layoutDiagram(lang, src)
+	if err != nil {
+		d = diagram{err: err.Error()}
+	} else {
+		d = diagram{svg: svg}
+	}
+	if err == errDiagramTimeout {
+		// a busy machine may manage it next time
+		return d
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; !ok {
+		c.items[key] = c.order.PushFront(&cachedDiagram{key: key, diagram: d})
+		if c.order.Len() > diagramCacheSize {
+			oldest := c.order.Remove(c.order.Back()).(*cachedDiagram)
+			delete(c.items, oldest.key)
+		}
+	}
+	return d
+}
+
+// Limits on a diagram, so a mistake in one can't hang or crash a reload.
+const (
+	diagramMaxSource = 32 << 10
+	diagramMaxSVG    = 1 << 20
+	diagramTimeout   = 2 * time.Second
+)
+
+var errDiagramTimeout = fmt.Errorf("took longer than %s to lay out", diagramTimeout)
+
+// layoutDiagram runs the layout for lang within the limits above, and
+// turns a panic in it into an error. The layouts check ctx as they go, so
+// one that runs out of time stops there.
+func layoutDiagram(lang, src string) (svg string, err error) {
+	if len(src) > diagramMaxSource {
+		return "", fmt.Errorf("source is over %d KB", diagramMaxSource>>10)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagramTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			svg, err = "", fmt.Errorf("layout failed: %v", r)
+		}
+	}()
+	svg, err = diagramLayouts[lang](ctx, src)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", errDiagramTimeout
+	}
+	if err == nil && len(svg) > diagramMaxSVG {
+		return "", fmt.Errorf("drawing is over %d KB", diagramMaxSVG>>10)
+	}
+	return svg, err
+}
+
+// diagramTransformer replaces diagram code blocks with their drawing.
+type diagramTransformer struct {
+	cache *diagramCache
+}
+
+func (t diagramTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var blocks []*ast.FencedCodeBlock
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if b, ok := n.(*ast.FencedCodeBlock); ok && entering {
+			if _, ok := diagramLayouts[string(b.Language(reader.Source()))]; ok {
+				blocks = append(blocks, b)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+
+	source := reader.Source()
+	for _, b := range blocks {
+		n := &diagramNode{Lang: string(b.Language(source))}
+		var src strings.Builder
+		for i := 0; i < b.Lines().Len(); i++ {
+			seg := b.Lines().At(i)
+			src.Write(seg.Value(source))
+		}
+		n.Source = src.String()
+		if b.Lines().Len() > 0 {
+			n.Offset = b.Lines().At(0).Start
+		} else if b.Info != nil {
+			n.Offset = b.Info.Segment.Start
+		}
+		d := t.cache.render(n.Lang, n.Source)
+		n.SVG, n.Err = d.svg, d.err
+		b.Parent().ReplaceChild(b.Parent(), b, n)
+	}
+}
+
+type diagramRenderer struct{}
+
+func (diagramRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindDiagram, renderDiagram)
+}
+
+func renderDiagram(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*diagramNode)
+	if n.Err != "" {
+		w.WriteString(`<pre class="diagram diagram-error" title="`)
+		w.Write(util.EscapeHTML([]byte(n.Err)))
+		w.WriteString(`"><code>`)
+		w.Write(util.EscapeHTML([]byte(n.Source)))
+		w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+	w.WriteString(`<div class="diagram diagram-` + n.Lang + `">` + n.SVG + "</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// diagramIssues reports the diagrams in d that failed to render.
+func diagramIssues(d *lintDoc) []lintIssue {
+	var issues []lintIssue
+	ast.Walk(d.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if n, ok := n.(*diagramNode); ok && entering && n.Err != "" {
+			issues = append(issues, lintIssue{File: d.Path, Line: offsetLine(d.Src, n.Offset), Msg: fmt.Sprintf("%s diagram: %s", n.Lang, n.Err)})
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
+
+// Diagrams are drawn in pixels at the body's font size, so they line up
+// with the text around them.
+const (
+	diagramFontSize = 13
+	diagramLineGap  = 17 // between lines of a label
+	diagramMargin   = 8
+)
+
+type point struct{ X, Y float64 }
+
+func (p point) add(q point) point     { return point{p.X + q.X, p.Y + q.Y} }
+func (p point) sub(q point) point     { return point{p.X - q.X, p.Y - q.Y} }
+func (p point) scale(f float64) point { return point{p.X * f, p.Y * f} }
+func (p point) dist(q point) float64  { return math.Hypot(p.X-q.X, p.Y-q.Y) }
+func (p point) mid(q point) point     { return point{(p.X + q.X) / 2, (p.Y + q.Y) / 2} }
+func (p point) String() string        { return num(p.X) + "," + num(p.Y) }
+func (p point) eq(q point) bool       { return p.dist(q) < 0.01 }
+func (p point) towards(q point, d float64) point {
+	l := p.dist(q)
+	if l == 0 {
+		return p
+	}
+	return p.add(q.sub(p).scale(d / l))
+}
+
+// textWidth estimates the width of s in the diagram font. It errs wide:
+// the font is the reader's sans-serif, unknown until the page is shown.
+func textWidth(s string) float64 {
+	return float64(utf8.RuneCountInString(s)) * diagramFontSize * 0.6
+}
+
+// labelSize is the size of a label with one line per entry in lines.
+func labelSize(lines []string) (w, h float64) {
+	for _, l := range lines {
+		w = max(w, textWidth(l))
+	}
+	return w, float64(len(lines)) * diagramLineGap
+}
+
+// num formats a coordinate to a tenth of a pixel.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+// svgCanvas collects SVG elements and the area they cover.
+type svgCanvas struct {
+	b                      strings.Builder
+	minX, minY, maxX, maxY float64
+	used                   bool
+}
+
+// cover grows the drawing's bounds to include the given box.
+func (c *svgCanvas) cover(x0, y0, x1, y1 float64) {
+	if !c.used {
+		c.minX, c.minY, c.maxX, c.maxY = x0, y0, x1, y1
+		c.used = true
+		return
+	}
+	c.minX, c.minY = min(c.minX, x0), min(c.minY, y0)
+	c.maxX, c.maxY = max(c.maxX, x1), max(c.maxY, y1)
+}
+
+func (c *svgCanvas) rect(x, y, w, h, r float64, attrs string) {
+	c.cover(x, y, x+w, y+h)
+	fmt.Fprintf(&c.b, `<rect x="%s" y="%s" width="%s" height="%s"`, num(x), num(y), num(w), num(h))
+	if r > 0 {
+		fmt.Fprintf(&c.b, ` rx="%s"`, num(r))
+	}
+	c.b.WriteString(attrs + "/>")
+}
+
+func (c *svgCanvas) ellipse(center point, rx, ry float64, attrs string) {
+	c.cover(center.X-rx, center.Y-ry, center.X+rx, center.Y+ry)
+	fmt.Fprintf(&c.b, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s"%s/>`, num(center.X), num(center.Y), num(rx), num(ry), attrs)
+}
+
+func (c *svgCanvas) polyline(pts []point, attrs string) {
+	c.points("polyline", pts, attrs)
+}
+
+func (c *svgCanvas) polygon(pts []point, attrs string) {
+	c.points("polygon", pts, attrs)
+}
+
+func (c *svgCanvas) points(elem string, pts []point, attrs string) {
+	s := make([]string, len(pts))
+	for i, p := range pts {
+		c.cover(p.X, p.Y, p.X, p.Y)
+		s[i] = p.String()
+	}
+	fmt.Fprintf(&c.b, `<%s points="%s"%s/>`, elem, strings.Join(s, " "), attrs)
+}
+
+// cylinder draws a cylinder filling the given box, with ends ry deep.
+func (c *svgCanvas) cylinder(x, y, w, h, ry float64, attrs string) {
+	c.cover(x, y, x+w, y+h)
+	top, bottom := y+ry, y+h-ry
+	r := num(w/2) + "," + num(ry)
+	c.path(fmt.Sprintf("M%s A%s 0 0 0 %s A%s 0 0 0 %s V%s A%s 0 0 0 %s V%s",
+		point{x, top}, r, point{x + w, top}, r, point{x, top}, num(bottom), r, point{x + w, bottom}, num(top)), attrs)
+}
+
+// path draws d, whose points must already be covered by the caller.
+func (c *svgCanvas) path(d, attrs string) {
+	fmt.Fprintf(&c.b, `<path d="%s"%s/>`, d, attrs)
+}
+
+// arrowHead draws a filled arrowhead with its tip at tip, pointing away
+// from from.
+func (c *svgCanvas) arrowHead(tip, from point) {
+	l := tip.dist(from)
+	if l == 0 {
+		return
+	}
+	u := tip.sub(from).scale(1 / l)
+	base := tip.sub(u.scale(9))
+	side := point{-u.Y, u.X}.scale(4)
+	c.polygon([]point{tip, base.add(side), base.sub(side)}, ` fill="currentColor" stroke="none"`)
+}
+
+// text writes the lines of a label centered on at, anchored by anchor
+// (start, middle or end).
+func (c *svgCanvas) text(at point, anchor string, lines []string) {
+	w, h := labelSize(lines)
+	x0 := at.X - w/2
+	switch anchor {
+	case "start":
+		x0 = at.X
+	case "end":
+		x0 = at.X - w
+	}
+	c.cover(x0, at.Y-h/2, x0+w, at.Y+h/2)
+	for i, l := range lines {
+		// the baseline sits a third of the font size below the line's middle
+		y := at.Y - h/2 + (float64(i)+0.5)*diagramLineGap + diagramFontSize*0.35
+		fmt.Fprintf(&c.b, `<text x="%s" y="%s" text-anchor="%s" fill="currentColor" stroke="none">`, num(at.X), num(y), anchor)
+		c.b.Write(util.EscapeHTML([]byte(l)))
+		c.b.WriteString("</text>")
+	}
+}
+
+// svg returns the drawing as an <svg> element sized to its contents.
+func (c *svgCanvas) svg(label string) string {
+	x, y := c.minX-diagramMargin, c.minY-diagramMargin
+	w, h := c.maxX-c.minX+2*diagramMargin, c.maxY-c.minY+2*diagramMargin
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="%s %s %s %s" width="%s" height="%s" role="img" aria-label="`,
+		num(x), num(y), num(w), num(h), num(w), num(h))
+	b.Write(util.EscapeHTML([]byte(label)))
+	fmt.Fprintf(&b, `"><g fill="none" stroke="currentColor" stroke-width="1.5" font-family="sans-serif" font-size="%d">`, diagramFontSize)
+	b.WriteString(c.b.String())
+	b.WriteString("</g></svg>")
+	return b.String()
+}
+
+// lineStyle returns the stroke attributes for a dashed or dotted line.
+func lineStyle(style string) string {
+	switch style {
+	case "dashed":
+		return ` stroke-dasharray="6 4"`
+	case "dotted":
+		return ` stroke-dasharray="1.5 3" stroke-linecap="round"`
+	}
+	return ""
+}
+
+// splitLabel splits a label on newlines and literal \n escapes.
+func splitLabel(s string) []string {
+	return strings.Split(strings.ReplaceAll(s, `\n`, "\n"), "\n")
+}
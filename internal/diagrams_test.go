@@ -0,0 +1,272 @@
+package blog
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var svgText = regexp.MustCompile(`<text x="([-\d.]+)" y="([-\d.]+)"[^>]*>([^<]*)</text>`)
+
+// textAt returns where each label in a rendered diagram is drawn.
+func textAt(t *testing.T, out string) map[string]point {
+	t.Helper()
+	at := make(map[string]point)
+	for _, m := range svgText.FindAllStringSubmatch(out, -1) {
+		x, _ := strconv.ParseFloat(m[1], 64)
+		y, _ := strconv.ParseFloat(m[2], 64)
+		at[m[3]] = point{x, y}
+	}
+	return at
+}
+
+func TestDotDiagram(t *testing.T) {
+	out := renderMarkdown(t, "```dot\n"+`digraph {
+  node [shape=box]
+  agent -> server [label="metrics"]
+  server -> db
+  db [shape=cylinder label="SQLite"]
+  tui -> server [style=dashed]
+}
+`+"```\n")
+
+	if !strings.HasPrefix(out, `<div class="diagram diagram-dot"><svg xmlns="http://www.w3.org/2000/svg"`) {
+		t.Fatalf("expected an inline svg, got: %s", out)
+	}
+	at := textAt(t, out)
+	for _, label := range []string{"agent", "server", "SQLite", "tui", "metrics"} {
+		if _, ok := at[label]; !ok {
+			t.Errorf("missing label %q in %s", label, out)
+		}
+	}
+	if !(at["agent"].Y < at["server"].Y && at["server"].Y < at["SQLite"].Y) {
+		t.Errorf("ranks should go down the page: %v", at)
+	}
+	if at["tui"].Y != at["agent"].Y {
+		t.Errorf("sources should share the top rank: %v", at)
+	}
+	if !strings.Contains(out, `stroke-dasharray="6 4"`) {
+		t.Error("dashed edge should be drawn dashed")
+	}
+
+	lr := textAt(t, renderMarkdown(t, "```dot\ndigraph { rankdir=LR; a -> b -> c; a -> c }\n```\n"))
+	if !(lr["a"].X < lr["b"].X && lr["b"].X < lr["c"].X) {
+		t.Errorf("LR ranks should go across the page: %v", lr)
+	}
+}
+
+func TestSequenceDiagram(t *testing.T) {
+	out := renderMarkdown(t, "```sequence\n"+`participant Browser
+participant "API server" as API
+Browser -> API: GET /posts
+API -> API: render
+API --> Browser: 200 OK
+note over API: cached
+`+"```\n")
+
+	at := textAt(t, out)
+	if !(at["Browser"].X < at["API server"].X) {
+		t.Errorf("participants should be laid out in order: %v", at)
+	}
+	if !(at["GET /posts"].Y < at["render"].Y && at["render"].Y < at["200 OK"].Y && at["200 OK"].Y < at["cached"].Y) {
+		t.Errorf("steps should go down the page in order: %v", at)
+	}
+	if at["cached"].X != at["API server"].X {
+		t.Errorf("note should be centered over its participant: %v", at)
+	}
+}
+
+func TestPikchrDiagram(t *testing.T) {
+	out := renderMarkdown(t, "```pikchr\n"+`A: box "client" fit
+arrow "SSH" above
+B: box "agent"
+down
+arrow from B.s
+DB: cylinder "SQLite"
+circle "!" at DB.e + (0.5, 0)
+arrow from DB to last circle
+`+"```\n")
+
+	if !strings.Contains(out, `<div class="diagram diagram-pikchr"><svg`) {
+		t.Fatalf("expected an inline svg, got: %s", out)
+	}
+	at := textAt(t, out)
+	if !(at["client"].X < at["SSH"].X && at["SSH"].X < at["agent"].X) || at["client"].Y != at["agent"].Y {
+		t.Errorf("objects should follow each other to the right: %v", at)
+	}
+	if at["SSH"].Y >= at["client"].Y {
+		t.Errorf("text above a line should be above it: %v", at)
+	}
+	if at["SQLite"].X != at["agent"].X || at["SQLite"].Y <= at["agent"].Y {
+		t.Errorf("cylinder should be below the agent: %v", at)
+	}
+	if !(at["!"].X > at["SQLite"].X) || math.Abs(at["!"].Y-at["SQLite"].Y) > 5 {
+		t.Errorf("circle should be placed at its offset: %v", at)
+	}
+}
+
+func TestDiagramErrors(t *testing.T) {
+	for _, tt := range []struct{ lang, src, msg string }{
+		{"dot", "digraph { a -> }", ""},
+		{"sequence", "Browser => API", "line 1: expected a participant, message or note"},
+		{"pikchr", "box\narrow from Nope.e", "line 2: unknown label &quot;Nope&quot;"},
+		{"pikchr", "box wid", "line 1: unexpected end of statement"},
+	} {
+		out := renderMarkdown(t, "```"+tt.lang+"\n"+tt.src+"\n```\n")
+		if !strings.HasPrefix(out, `<pre class="diagram diagram-error" title="`) || !strings.Contains(out, tt.msg) {
+			t.Errorf("%s %q: expected the source with the error, got: %s", tt.lang, tt.src, out)
+		}
+	}
+
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-diagram.md": "---\ntitle: Diagram\ndate: 2026-01-01\n---\nIntro.\n\n```pikchr\nbox\nfrobnicate\n```\n",
+	})
+	issues := postRenderIssues(t, dir, newMarkdown())
+	if len(issues) != 1 || issues[0].Line != 8 || !strings.Contains(issues[0].Msg, "pikchr diagram: line 2:") {
+		t.Errorf("issues = %v, want one for line 8", issues)
+	}
+}
+
+// TestDiagramUnsupported pins down what the README says falls back to
+// showing the source.
+func TestDiagramUnsupported(t *testing.T) {
+	for _, tt := range []struct{ lang, src, msg string }{
+		{"dot", "digraph { a -> b", "expected one of"},
+		{"dot", "flowchart TD\nA-->B", "expected one of"},
+		{"pikchr", "x = 1\nbox", "line 1: unexpected '='"},
+		{"pikchr", "[ box ]", "line 1: unexpected '['"},
+		{"pikchr", "arc", `line 1: unexpected "arc"`},
+		{"pikchr", "define b { box }", "line 1: unexpected '{'"},
+		{"pikchr", "A: box\nbox with .n at A.s", `line 2: unexpected "with"`},
+		{"pikchr", "box wid 2*0.5", `line 1: unexpected "*"`},
+		{"pikchr", "A: box\nB: box\ndot at 1/2 way between A and B", "line 3: unexpected '/'"},
+		{"pikchr", "box; arrow right until even with 1st box", `line 1: unexpected "until"`},
+		{"pikchr", "box; box heading 45", `line 1: unexpected "heading"`},
+		{"pikchr", "box color", "line 1: bad color"},
+		{"sequence", "A ->> B: hi", "line 1: expected a participant, message or note"},
+		{"sequence", "activate A", "line 1: expected a participant, message or note"},
+		{"sequence", "loop every 5s", "line 1: expected a participant, message or note"},
+		{"sequence", "Note left of A: x", "line 1: expected a participant, message or note"},
+	} {
+		_, err := diagramLayouts[tt.lang](context.Background(), tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s %q: err = %v, want %q", tt.lang, tt.src, err, tt.msg)
+		}
+	}
+}
+
+func TestDiagramCache(t *testing.T) {
+	layouts := 0
+	layout := diagramLayouts["sequence"]
+	diagramLayouts["sequence"] = func(ctx context.Context, src string) (string, error) {
+		layouts++
+		return layout(ctx, src)
+	}
+	t.Cleanup(func() { diagramLayouts["sequence"] = layout })
+
+	md := newMarkdown()
+	src := "```sequence\nA -> B: hi\n```\n\n```sequence\nA -> B: hi\n```\n"
+	var first, second strings.Builder
+	if err := md.Convert([]byte(src), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := md.Convert([]byte(src), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Error("cached diagram should render the same")
+	}
+	if layouts != 1 {
+		t.Errorf("diagram laid out %d times, want once", layouts)
+	}
+}
+
+func TestDiagramCacheEvicts(t *testing.T) {
+	c := newDiagramExtension().cache
+	for i := range diagramCacheSize + 1 {
+		c.render("sequence", fmt.Sprintf("A -> B: %d", i))
+	}
+	if c.order.Len() != diagramCacheSize {
+		t.Errorf("cache holds %d diagrams, want %d", c.order.Len(), diagramCacheSize)
+	}
+	if _, ok := c.items[sha256.Sum256([]byte("sequence\x00A -> B: 0"))]; ok {
+		t.Error("the least recently used diagram should have been dropped")
+	}
+}
+
+func TestDiagramLimits(t *testing.T) {
+	layout := diagramLayouts["sequence"]
+	diagramLayouts["sequence"] = func(ctx context.Context, src string) (string, error) {
+		panic("boom")
+	}
+	t.Cleanup(func() { diagramLayouts["sequence"] = layout })
+
+	out := renderMarkdown(t, "```sequence\nA -> B\n```\n")
+	if !strings.HasPrefix(out, `<pre class="diagram diagram-error" title="layout failed: boom">`) {
+		t.Errorf("a panicking layout should show the source: %s", out)
+	}
+
+	out = renderMarkdown(t, "```pikchr\n"+strings.Repeat("box\n", diagramMaxSource/4+1)+"```\n")
+	if !strings.Contains(out, `title="source is over 32 KB"`) {
+		t.Errorf("an oversized diagram should show the source: %s", out[:200])
+	}
+
+	out = renderMarkdown(t, "```pikchr\n"+strings.Repeat("box\n", pikMaxObjects+1)+"```\n")
+	if !strings.Contains(out, `title="line 501: over 500 objects"`) {
+		t.Errorf("a diagram with too many objects should show the source: %s", out[:200])
+	}
+
+	var g strings.Builder
+	g.WriteString("digraph {\n")
+	for i := range dotMaxElements / 2 {
+		fmt.Fprintf(&g, "n%d -> n%d\n", i, i+1)
+	}
+	out = renderMarkdown(t, "```dot\n"+g.String()+"}\n```\n")
+	if !strings.Contains(out, `title="graph has over 500 nodes and edges"`) {
+		t.Errorf("a graph with too many nodes should show the source: %s", out[:200])
+	}
+}
+
+func TestDiagramDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	for lang, src := range map[string]string{"dot": "digraph { a -> b }", "pikchr": "box", "sequence": "A -> B"} {
+		if _, err := diagramLayouts[lang](ctx, src); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: err = %v, want the layout to stop at the deadline", lang, err)
+		}
+	}
+
+	layouts := 0
+	layout := diagramLayouts["sequence"]
+	diagramLayouts["sequence"] = func(ctx context.Context, src string) (string, error) {
+		layouts++
+		if d, ok := ctx.Deadline(); !ok || time.Until(d) > diagramTimeout {
+			t.Errorf("layout deadline = %v, want one within %s", d, diagramTimeout)
+		}
+		return "", context.DeadlineExceeded
+	}
+	t.Cleanup(func() { diagramLayouts["sequence"] = layout })
+
+	src := "```sequence\nA -> B\n```\n"
+	out := renderMarkdown(t, src+"\n"+src)
+	if !strings.HasPrefix(out, `<pre class="diagram diagram-error" title="took longer than 2s to lay out">`) {
+		t.Errorf("a slow layout should show the source: %s", out)
+	}
+	if layouts != 2 {
+		t.Errorf("diagram laid out %d times, want timeouts left out of the cache", layouts)
+	}
+}
+
+func TestDiagramLanguagesOnly(t *testing.T) {
+	out := renderMarkdown(t, "```go\nfunc main() {}\n```\n")
+	if strings.Contains(out, "<svg") {
+		t.Errorf("other code blocks should stay code: %s", out)
+	}
+}
@@ -0,0 +1,633 @@
+package blog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/awalterschulze/gographviz"
+)
+
+// DOT graphs are parsed with gographviz and laid out in layers, the way
+// Graphviz's dot does it, only simpler: cycles are broken by reversing
+// back edges, nodes are ranked by longest path, long edges get invisible
+// waypoints, and each layer is ordered by the barycenter of its
+// neighbours to cut down crossings. Subgraphs are flattened; clusters
+// aren't drawn.
+//
+// Supported attributes: rankdir (TB or LR), label, shape (box, ellipse,
+// circle, diamond, cylinder, plaintext) and style=dashed|dotted on nodes
+// and edges, and dir=back|none or arrowhead=none on edges.
+
+const (
+	dotNodeSep = 28
+	dotRankSep = 44
+	dotSweeps  = 8
+
+	// bigger graphs are shown as source: ordering the layers gets slow
+	dotMaxElements  = 500  // nodes and edges
+	dotMaxWaypoints = 5000 // nodes once long edges are split
+)
+
+type dotNode struct {
+	label   []string
+	shape   string
+	style   string
+	w, h    float64 // along and across the layers
+	rank    int
+	order   int
+	pos     float64 // center, along the layer
+	virtual bool
+}
+
+type dotEdge struct {
+	path     []int // nodes from source to target, through waypoints
+	label    []string
+	style    string
+	arrow    bool
+	back     bool // the arrow points at the source
+	reversed bool
+	self     bool
+}
+
+func layoutDot(ctx context.Context, src string) (string, error) {
+	g, err := gographviz.Read([]byte(src))
+	if err != nil {
+		return "", fmt.Errorf("%s", firstLine(err.Error()))
+	}
+	lr := strings.ToUpper(unquoteDot(g.Attrs["rankdir"])) == "LR"
+
+	var nodes []*dotNode
+	index := make(map[string]int)
+	addNode := func(name string, attrs gographviz.Attrs) int {
+		if i, ok := index[name]; ok {
+			return i
+		}
+		label := unquoteDot(name)
+		if l, ok := attrs["label"]; ok {
+			label = unquoteDot(l)
+		}
+		n := &dotNode{
+			label: splitLabel(label),
+			shape: strings.ToLower(unquoteDot(attrs["shape"])),
+			style: strings.ToLower(unquoteDot(attrs["style"])),
+		}
+		n.w, n.h = dotNodeSize(n)
+		if lr {
+			n.w, n.h = n.h, n.w
+		}
+		index[name] = len(nodes)
+		nodes = append(nodes, n)
+		return len(nodes) - 1
+	}
+	for _, n := range g.Nodes.Nodes {
+		if !g.IsSubGraph(n.Name) {
+			addNode(n.Name, n.Attrs)
+		}
+	}
+
+	var edges []*dotEdge
+	for _, e := range g.Edges.Edges {
+		if g.IsSubGraph(e.Src) || g.IsSubGraph(e.Dst) {
+			continue
+		}
+		u, v := addNode(e.Src, nil), addNode(e.Dst, nil)
+		dir := unquoteDot(e.Attrs["dir"])
+		edge := &dotEdge{
+			path:  []int{u, v},
+			label: splitLabel(unquoteDot(e.Attrs["label"])),
+			style: strings.ToLower(unquoteDot(e.Attrs["style"])),
+			arrow: g.Directed && dir != "none" && unquoteDot(e.Attrs["arrowhead"]) != "none",
+			back:  dir == "back",
+			self:  u == v,
+		}
+		if edge.label[0] == "" {
+			edge.label = nil
+		}
+		edges = append(edges, edge)
+	}
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("empty graph")
+	}
+	if len(nodes)+len(edges) > dotMaxElements {
+		return "", fmt.Errorf("graph has over %d nodes and edges", dotMaxElements)
+	}
+
+	rankNodes(nodes, edges)
+	layers, err := addWaypoints(&nodes, edges)
+	if err != nil {
+		return "", err
+	}
+	if err := orderLayers(ctx, layers, nodes, edges); err != nil {
+		return "", err
+	}
+	if err := placeLayers(ctx, layers, nodes, edges); err != nil {
+		return "", err
+	}
+	return drawDot(nodes, edges, layers, lr), nil
+}
+
+// dotNodeSize returns a node's size with its label and shape.
+func dotNodeSize(n *dotNode) (w, h float64) {
+	tw, th := labelSize(n.label)
+	w, h = max(tw+24, 54), max(th+14, 34)
+	switch n.shape {
+	case "circle", "doublecircle":
+		d := max(math.Hypot(tw, th)+12, 34)
+		return d, d
+	case "diamond":
+		return w * 1.6, h * 1.6
+	case "plaintext", "plain", "none":
+		return tw + 8, th + 4
+	case "cylinder":
+		return w, h + 12
+	case "box", "rect", "rectangle", "square", "record", "mrecord", "note", "folder", "component":
+		return w, h
+	}
+	return w * 1.2, h
+}
+
+// rankNodes assigns each node a layer: reversing back edges found by a
+// depth-first search makes the graph acyclic, then every node goes one
+// below its lowest predecessor.
+func rankNodes(nodes []*dotNode, edges []*dotEdge) {
+	out := make([][]*dotEdge, len(nodes))
+	for _, e := range edges {
+		if !e.self {
+			out[e.path[0]] = append(out[e.path[0]], e)
+		}
+	}
+	state := make([]int, len(nodes)) // 0 new, 1 on the stack, 2 done
+	var visit func(int)
+	visit = func(u int) {
+		state[u] = 1
+		for _, e := range out[u] {
+			v := e.path[1]
+			switch state[v] {
+			case 0:
+				visit(v)
+			case 1:
+				e.reversed = true
+			}
+		}
+		state[u] = 2
+	}
+	for u := range nodes {
+		if state[u] == 0 {
+			visit(u)
+		}
+	}
+
+	// longest path, in topological order
+	indeg := make([]int, len(nodes))
+	succ := make([][]int, len(nodes))
+	for _, e := range edges {
+		if e.self {
+			continue
+		}
+		u, v := e.path[0], e.path[1]
+		if e.reversed {
+			u, v = v, u
+		}
+		succ[u] = append(succ[u], v)
+		indeg[v]++
+	}
+	var queue []int
+	for u := range nodes {
+		if indeg[u] == 0 {
+			queue = append(queue, u)
+		}
+	}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, v := range succ[u] {
+			nodes[v].rank = max(nodes[v].rank, nodes[u].rank+1)
+			if indeg[v]--; indeg[v] == 0 {
+				queue = append(queue, v)
+			}
+		}
+	}
+}
+
+// addWaypoints splits edges that span more than one layer with virtual
+// nodes, and returns the nodes of each layer.
+func addWaypoints(nodes *[]*dotNode, edges []*dotEdge) ([][]int, error) {
+	for _, e := range edges {
+		if e.self {
+			continue
+		}
+		u, v := e.path[0], e.path[1]
+		ru, rv := (*nodes)[u].rank, (*nodes)[v].rank
+		step := 1
+		if rv < ru {
+			step = -1
+		}
+		path := []int{u}
+		for r := ru + step; r != rv; r += step {
+			if len(*nodes) == dotMaxWaypoints {
+				return nil, fmt.Errorf("graph has too many long edges to lay out")
+			}
+			(*nodes) = append(*nodes, &dotNode{rank: r, w: 8, h: 8, virtual: true})
+			path = append(path, len(*nodes)-1)
+		}
+		e.path = append(path, v)
+	}
+
+	var layers [][]int
+	for i, n := range *nodes {
+		for len(layers) <= n.rank {
+			layers = append(layers, nil)
+		}
+		n.order = len(layers[n.rank])
+		layers[n.rank] = append(layers[n.rank], i)
+	}
+	return layers, nil
+}
+
+// layerLinks returns, for each node, its neighbours in the layer above and
+// the layer below.
+func layerLinks(nodes []*dotNode, edges []*dotEdge) (up, down [][]int) {
+	up, down = make([][]int, len(nodes)), make([][]int, len(nodes))
+	for _, e := range edges {
+		for i := 1; i < len(e.path); i++ {
+			a, b := e.path[i-1], e.path[i]
+			if nodes[a].rank > nodes[b].rank {
+				a, b = b, a
+			}
+			down[a] = append(down[a], b)
+			up[b] = append(up[b], a)
+		}
+	}
+	return up, down
+}
+
+// orderLayers reorders each layer by the mean position of its neighbours,
+// sweeping down and up, and keeps the order with the fewest crossings.
+func orderLayers(ctx context.Context, layers [][]int, nodes []*dotNode, edges []*dotEdge) error {
+	up, down := layerLinks(nodes, edges)
+	best, err := crossings(ctx, layers, nodes, down)
+	if err != nil {
+		return err
+	}
+	bestOrder := saveOrder(nodes)
+	for sweep := 0; sweep < dotSweeps && best > 0; sweep++ {
+		if sweep%2 == 0 {
+			for r := 1; r < len(layers); r++ {
+				sortByBarycenter(layers[r], nodes, up)
+			}
+		} else {
+			for r := len(layers) - 2; r >= 0; r-- {
+				sortByBarycenter(layers[r], nodes, down)
+			}
+		}
+		c, err := crossings(ctx, layers, nodes, down)
+		if err != nil {
+			return err
+		}
+		if c < best {
+			best, bestOrder = c, saveOrder(nodes)
+		}
+	}
+	for i, n := range nodes {
+		n.order = bestOrder[i]
+	}
+	for _, layer := range layers {
+		sort.Slice(layer, func(i, j int) bool { return nodes[layer[i]].order < nodes[layer[j]].order })
+	}
+	return nil
+}
+
+func saveOrder(nodes []*dotNode) []int {
+	order := make([]int, len(nodes))
+	for i, n := range nodes {
+		order[i] = n.order
+	}
+	return order
+}
+
+func sortByBarycenter(layer []int, nodes []*dotNode, links [][]int) {
+	center := make(map[int]float64, len(layer))
+	for _, u := range layer {
+		if len(links[u]) == 0 {
+			center[u] = float64(nodes[u].order)
+			continue
+		}
+		var sum float64
+		for _, v := range links[u] {
+			sum += float64(nodes[v].order)
+		}
+		center[u] = sum / float64(len(links[u]))
+	}
+	sort.SliceStable(layer, func(i, j int) bool { return center[layer[i]] < center[layer[j]] })
+	for i, u := range layer {
+		nodes[u].order = i
+	}
+}
+
+// crossings counts the edge crossings between adjacent layers.
+func crossings(ctx context.Context, layers [][]int, nodes []*dotNode, down [][]int) (int, error) {
+	count := 0
+	for _, layer := range layers {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		var segs [][2]int
+		for _, u := range layer {
+			for _, v := range down[u] {
+				segs = append(segs, [2]int{nodes[u].order, nodes[v].order})
+			}
+		}
+		for i := range segs {
+			for j := i + 1; j < len(segs); j++ {
+				a, b := segs[i], segs[j]
+				if (a[0]-b[0])*(a[1]-b[1]) < 0 {
+					count++
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+// placeLayers positions the nodes of each layer, pulling each towards its
+// neighbours while keeping the layer's order and spacing.
+func placeLayers(ctx context.Context, layers [][]int, nodes []*dotNode, edges []*dotEdge) error {
+	up, down := layerLinks(nodes, edges)
+	for _, layer := range layers {
+		var x float64
+		for _, u := range layer {
+			nodes[u].pos = x + nodes[u].w/2
+			x += nodes[u].w + dotNodeSep
+		}
+	}
+	for pass := 0; pass < 6; pass++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		links := down
+		if pass%2 == 0 {
+			links = up
+		}
+		for _, layer := range layers {
+			want := make([]float64, len(layer))
+			for i, u := range layer {
+				want[i] = nodes[u].pos
+				if ns := links[u]; len(ns) > 0 {
+					var sum float64
+					for _, v := range ns {
+						sum += nodes[v].pos
+					}
+					want[i] = sum / float64(len(ns))
+				}
+			}
+			spaceLayer(layer, nodes, want)
+		}
+	}
+
+	minX := math.Inf(1)
+	for _, n := range nodes {
+		minX = min(minX, n.pos-n.w/2)
+	}
+	for _, n := range nodes {
+		n.pos -= minX
+	}
+	return nil
+}
+
+// spaceLayer moves the nodes of a layer as close to want as their order
+// and spacing allow: the mean of packing them from the left and from the
+// right, which both keep every gap.
+func spaceLayer(layer []int, nodes []*dotNode, want []float64) {
+	gap := func(i int) float64 {
+		return (nodes[layer[i-1]].w+nodes[layer[i]].w)/2 + dotNodeSep
+	}
+	right := make([]float64, len(layer))
+	for i := range layer {
+		right[i] = want[i]
+		if i > 0 {
+			right[i] = max(right[i], right[i-1]+gap(i))
+		}
+	}
+	left := make([]float64, len(layer))
+	for i := len(layer) - 1; i >= 0; i-- {
+		left[i] = want[i]
+		if i < len(layer)-1 {
+			left[i] = min(left[i], left[i+1]-gap(i+1))
+		}
+	}
+	for i, u := range layer {
+		nodes[u].pos = (left[i] + right[i]) / 2
+	}
+}
+
+func drawDot(nodes []*dotNode, edges []*dotEdge, layers [][]int, lr bool) string {
+	// a layer is as deep as its deepest node, plus room for edge labels
+	// in the gap below it
+	depth := make([]float64, len(layers))
+	labelGap := make([]float64, len(layers))
+	for r, layer := range layers {
+		for _, u := range layer {
+			depth[r] = max(depth[r], nodes[u].h)
+		}
+	}
+	for _, e := range edges {
+		if e.self || e.label == nil {
+			continue
+		}
+		a, b := nodes[e.path[0]].rank, nodes[e.path[1]].rank
+		w, h := labelSize(e.label)
+		if lr {
+			h = w
+		}
+		r := min(a, b)
+		labelGap[r] = max(labelGap[r], h)
+	}
+	offset := make([]float64, len(layers))
+	var y float64
+	for r := range layers {
+		offset[r] = y + depth[r]/2
+		y += depth[r] + dotRankSep + labelGap[r]
+	}
+
+	at := func(u int) point {
+		n := nodes[u]
+		if lr {
+			return point{offset[n.rank], n.pos}
+		}
+		return point{n.pos, offset[n.rank]}
+	}
+
+	// edges between the same two nodes are drawn side by side
+	pairs := make(map[[2]int][]*dotEdge)
+	for _, e := range edges {
+		if !e.self {
+			pairs[e.ends()] = append(pairs[e.ends()], e)
+		}
+	}
+	c := &svgCanvas{}
+	for _, e := range edges {
+		var spread float64
+		if !e.self {
+			same := pairs[e.ends()]
+			spread = (float64(slices.Index(same, e)) - float64(len(same)-1)/2) * 10
+		}
+		drawDotEdge(c, e, nodes, at, lr, spread)
+	}
+	for u, n := range nodes {
+		if !n.virtual {
+			drawDotNode(c, n, at(u), lr)
+		}
+	}
+	return c.svg("Graph")
+}
+
+// size returns a node's width and height on the page.
+func (n *dotNode) size(lr bool) (w, h float64) {
+	if lr {
+		return n.h, n.w
+	}
+	return n.w, n.h
+}
+
+func drawDotNode(c *svgCanvas, n *dotNode, at point, lr bool) {
+	w, h := n.size(lr)
+	attrs := lineStyle(n.style)
+	switch n.shape {
+	case "box", "rect", "rectangle", "square", "record", "note", "folder", "component":
+		c.rect(at.X-w/2, at.Y-h/2, w, h, 0, attrs)
+	case "mrecord":
+		c.rect(at.X-w/2, at.Y-h/2, w, h, 6, attrs)
+	case "circle":
+		c.ellipse(at, w/2, h/2, attrs)
+	case "doublecircle":
+		c.ellipse(at, w/2, h/2, attrs)
+		c.ellipse(at, w/2-4, h/2-4, attrs)
+	case "diamond":
+		c.polygon([]point{{at.X, at.Y - h/2}, {at.X + w/2, at.Y}, {at.X, at.Y + h/2}, {at.X - w/2, at.Y}}, attrs)
+	case "cylinder":
+		c.cylinder(at.X-w/2, at.Y-h/2, w, h, 6, attrs)
+	case "plaintext", "plain", "none":
+	default:
+		c.ellipse(at, w/2, h/2, attrs)
+	}
+	if n.shape == "cylinder" {
+		at.Y += 4
+	}
+	c.text(at, "middle", n.label)
+}
+
+// ends returns the nodes an edge joins, lowest first.
+func (e *dotEdge) ends() [2]int {
+	u, v := e.path[0], e.path[len(e.path)-1]
+	return [2]int{min(u, v), max(u, v)}
+}
+
+// drawDotEdge draws e, moved sideways by spread pixels to keep it clear
+// of other edges between the same nodes.
+func drawDotEdge(c *svgCanvas, e *dotEdge, nodes []*dotNode, at func(int) point, lr bool, spread float64) {
+	attrs := lineStyle(e.style)
+	if e.self {
+		n := nodes[e.path[0]]
+		w, h := n.size(lr)
+		p := at(e.path[0])
+		x := p.X + w/2
+		top, bottom := point{x - 2, p.Y - h/4}, point{x - 2, p.Y + h/4}
+		c.cover(x, p.Y-h/2, x+28, p.Y+h/2)
+		c.path(fmt.Sprintf("M%s C%s %s %s", top, point{x + 28, p.Y - h/2}, point{x + 28, p.Y + h/2}, bottom), attrs)
+		if e.arrow {
+			c.arrowHead(bottom, point{x + 14, p.Y + h/3})
+		}
+		if e.label != nil {
+			c.text(point{x + 32, p.Y}, "start", e.label)
+		}
+		return
+	}
+
+	pts := make([]point, len(e.path))
+	for i, u := range e.path {
+		pts[i] = at(u)
+	}
+	if spread != 0 {
+		ends := e.ends()
+		a, b := at(ends[0]), at(ends[1])
+		side := point{a.Y - b.Y, b.X - a.X}.scale(spread / a.dist(b))
+		for i := range pts {
+			pts[i] = pts[i].add(side)
+		}
+	}
+	pts[0] = clipToNode(nodes[e.path[0]], pts[0], pts[1], lr)
+	last := len(pts) - 1
+	pts[last] = clipToNode(nodes[e.path[last]], pts[last], pts[last-1], lr)
+	if e.back {
+		slices.Reverse(pts)
+	}
+
+	if e.arrow {
+		tip := pts[last]
+		pts[last] = tip.towards(pts[last-1], 8)
+		c.polyline(pts, attrs)
+		c.arrowHead(tip, pts[last-1])
+	} else {
+		c.polyline(pts, attrs)
+	}
+	if e.label != nil {
+		mid := pts[0].mid(pts[1])
+		if len(pts) > 2 {
+			mid = pts[len(pts)/2]
+		}
+		w, _ := labelSize(e.label)
+		if lr {
+			c.text(point{mid.X, mid.Y - diagramLineGap*float64(len(e.label))/2 - 4}, "middle", e.label)
+		} else {
+			c.text(point{mid.X + 6 + w/2, mid.Y}, "middle", e.label)
+		}
+	}
+}
+
+// clipToNode moves an edge's end from the center of n to its outline, on
+// the way towards next.
+func clipToNode(n *dotNode, center, next point, lr bool) point {
+	w, h := n.size(lr)
+	d := next.sub(center)
+	if d.X == 0 && d.Y == 0 {
+		return center
+	}
+	a, b := w/2, h/2
+	var t float64
+	switch n.shape {
+	case "box", "rect", "rectangle", "square", "record", "mrecord", "note", "folder", "component", "cylinder", "plaintext", "plain", "none":
+		t = math.Inf(1)
+		if d.X != 0 {
+			t = a / math.Abs(d.X)
+		}
+		if d.Y != 0 {
+			t = min(t, b/math.Abs(d.Y))
+		}
+	case "diamond":
+		t = 1 / (math.Abs(d.X)/a + math.Abs(d.Y)/b)
+	default:
+		t = 1 / math.Hypot(d.X/a, d.Y/b)
+	}
+	return center.add(d.scale(min(t, 1)))
+}
+
+// unquoteDot returns a DOT ID as written, without its quotes.
+func unquoteDot(id string) string {
+	if strings.HasPrefix(id, `"`) {
+		if s, err := strconv.Unquote(id); err == nil {
+			return s
+		}
+		return strings.Trim(id, `"`)
+	}
+	return id
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
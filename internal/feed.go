@@ -16,7 +16,7 @@ type rssChannel struct {
 	Title       string    `xml:"title"`
 	Link        string    `xml:"link"`
 	Description string    `xml:"description"`
-	LastBuild   string    `xml:"lastBuildDate"`
+	LastBuild   string    `xml:"lastBuildDate,omitempty"`
 	Items       []rssItem `xml:"item"`
 }
 
@@ -25,6 +25,7 @@ type rssItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	Updated     string `xml:"http://www.w3.org/2005/Atom updated,omitempty"` // RSS has no edit date
 	GUID        string `xml:"guid"`
 }
 
@@ -33,8 +34,13 @@ func (app *App) handleRSS(w http.ResponseWriter, r *http.Request) {
 	posts := publicPosts(app.posts)
 	app.mu.RUnlock()
 
+	// The feed changes when a post is added or edited
+	var lastBuild time.Time
 	items := make([]rssItem, len(posts))
 	for i, p := range posts {
+		if m := p.lastModified(); m.After(lastBuild) {
+			lastBuild = m
+		}
 		link := app.cfg.BaseURL + "/posts/" + p.Slug
 		items[i] = rssItem{
 			Title:       p.Title,
@@ -43,17 +49,27 @@ func (app *App) handleRSS(w http.ResponseWriter, r *http.Request) {
 			PubDate:     p.Date.Format(time.RFC1123Z),
 			GUID:        link,
 		}
+		if p.Updated.After(p.Date) {
+			items[i].Updated = p.Updated.Format(time.RFC3339)
+		}
+	}
+
+	ch := rssChannel{
+		Title:       "thobiasn.dev",
+		Link:        app.cfg.BaseURL,
+		Description: "Personal blog by thobiasn",
+		Items:       items,
+	}
+	if !lastBuild.IsZero() { // no public posts yet
+		ch.LastBuild = lastBuild.Format(time.RFC1123Z)
 	}
+	writeRSS(w, ch)
+}
 
+func writeRSS(w http.ResponseWriter, ch rssChannel) {
 	feed := rssFeed{
 		Version: "2.0",
-		Channel: rssChannel{
-			Title:       "thobiasn.dev",
-			Link:        app.cfg.BaseURL,
-			Description: "Personal blog by thobiasn",
-			LastBuild:   time.Now().Format(time.RFC1123Z),
-			Items:       items,
-		},
+		Channel: ch,
 	}
 
 	w.Header().Set("Content-Type", "application/rss+xml")
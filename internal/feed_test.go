@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHandleRSS(t *testing.T) {
@@ -34,3 +35,28 @@ func TestHandleRSS(t *testing.T) {
 		t.Error("private post should not appear in RSS")
 	}
 }
+
+func TestHandleRSSEmpty(t *testing.T) {
+	app := testApp(t)
+	app.posts = nil
+
+	w := httptest.NewRecorder()
+	app.handleRSS(w, httptest.NewRequest("GET", "/rss.xml", nil))
+	if body := w.Body.String(); strings.Contains(body, "lastBuildDate") {
+		t.Errorf("empty feed has a lastBuildDate:\n%s", body)
+	}
+}
+
+func TestHandleRSSUpdated(t *testing.T) {
+	app := testApp(t)
+	app.posts[0].Updated = app.posts[0].Date.AddDate(0, 1, 0)
+
+	req := httptest.NewRequest("GET", "/rss.xml", nil)
+	w := httptest.NewRecorder()
+	app.handleRSS(w, req)
+
+	want := `<updated xmlns="http://www.w3.org/2005/Atom">` + app.posts[0].Updated.Format(time.RFC3339) + `</updated>`
+	if body := w.Body.String(); strings.Count(body, "<updated") != 1 || !strings.Contains(body, want) {
+		t.Errorf("feed should carry the edited post's updated date only:\n%s", body)
+	}
+}
@@ -0,0 +1,178 @@
+package blog
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Figures turn an image on its own line into <figure> when it has a
+// caption: either an emphasized line straight after it,
+//
+//	![tori dashboard](/images/tori.png)
+//	*The default Tokyo Night theme*
+//
+// or a title, ![alt](/images/tori.png "The default theme"). Images between
+// :::gallery and ::: are laid out as a grid of figures, each linking to
+// the full-size image.
+
+var (
+	kindFigure        = ast.NewNodeKind("Figure")
+	kindFigureCaption = ast.NewNodeKind("FigureCaption")
+	kindGallery       = ast.NewNodeKind("Gallery")
+)
+
+type figureNode struct{ ast.BaseBlock }
+
+func (n *figureNode) Kind() ast.NodeKind { return kindFigure }
+
+func (n *figureNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type figureCaption struct{ ast.BaseBlock }
+
+func (n *figureCaption) Kind() ast.NodeKind { return kindFigureCaption }
+
+func (n *figureCaption) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type galleryNode struct {
+	ast.BaseBlock
+	fence
+}
+
+func (n *galleryNode) Kind() ast.NodeKind { return kindGallery }
+
+func (n *galleryNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+type figureExtension struct{}
+
+func (figureExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(util.Prioritized(figureTransformer{}, 500)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(figureRenderer{}, 500),
+	))
+}
+
+// figureItem is an image and the emphasis node captioning it, if any.
+type figureItem struct {
+	image   *ast.Image
+	caption *ast.Emphasis
+}
+
+// figureItems splits a paragraph made up only of images, each optionally
+// followed by an emphasized line, into items. ok is false if anything
+// else is in the paragraph.
+func figureItems(p *ast.Paragraph, source []byte) (items []figureItem, ok bool) {
+	newline := false
+	for c := p.FirstChild(); c != nil; c = c.NextSibling() {
+		switch n := c.(type) {
+		case *ast.Image:
+			items = append(items, figureItem{image: n})
+			newline = false
+		case *ast.Text:
+			if len(bytes.TrimSpace(n.Segment.Value(source))) > 0 {
+				return nil, false
+			}
+			newline = newline || n.SoftLineBreak()
+		case *ast.Emphasis:
+			last := len(items) - 1
+			if n.Level != 1 || last < 0 || !newline || items[last].caption != nil {
+				return nil, false
+			}
+			items[last].caption = n
+			newline = false
+		default:
+			return nil, false
+		}
+	}
+	return items, len(items) > 0
+}
+
+type figureTransformer struct{}
+
+func (figureTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	var paras []*ast.Paragraph
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if p, ok := n.(*ast.Paragraph); ok && entering {
+			paras = append(paras, p)
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+
+	source := reader.Source()
+	for _, p := range paras {
+		items, ok := figureItems(p, source)
+		if !ok {
+			continue
+		}
+		parent := p.Parent()
+		_, inGallery := parent.(*galleryNode)
+		if !inGallery && (len(items) > 1 || (items[0].caption == nil && items[0].image.Title == nil)) {
+			continue
+		}
+		for _, item := range items {
+			parent.InsertBefore(parent, p, newFigure(item, inGallery))
+		}
+		parent.RemoveChild(parent, p)
+	}
+}
+
+func newFigure(item figureItem, link bool) *figureNode {
+	fig := &figureNode{}
+	img := item.image
+	var caption *figureCaption
+	switch {
+	case item.caption != nil:
+		caption = &figureCaption{}
+		for c := item.caption.FirstChild(); c != nil; {
+			next := c.NextSibling()
+			caption.AppendChild(caption, c)
+			c = next
+		}
+	case img.Title != nil:
+		// the caption replaces the tooltip rather than repeating it
+		caption = &figureCaption{}
+		caption.AppendChild(caption, ast.NewString(img.Title))
+		img.Title = nil
+	}
+
+	if link {
+		a := ast.NewLink()
+		a.Destination = img.Destination
+		a.AppendChild(a, img)
+		fig.AppendChild(fig, a)
+	} else {
+		fig.AppendChild(fig, img)
+	}
+	if caption != nil {
+		fig.AppendChild(fig, caption)
+	}
+	return fig
+}
+
+type figureRenderer struct{}
+
+func (figureRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindFigure, renderTag("<figure>", "</figure>\n"))
+	reg.Register(kindFigureCaption, renderTag("<figcaption>", "</figcaption>"))
+	reg.Register(kindGallery, renderTag(`<div class="gallery">`+"\n", "</div>\n"))
+}
+
+// renderTag renders a node as open and close markup around its children.
+func renderTag(open, close string) renderer.NodeRendererFunc {
+	return func(w util.BufWriter, source []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			w.WriteString(open)
+		} else {
+			w.WriteString(close)
+		}
+		return ast.WalkContinue, nil
+	}
+}
@@ -0,0 +1,111 @@
+package blog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFigures(t *testing.T) {
+	md := newMarkdown()
+
+	for _, tt := range []struct {
+		name, src string
+		want      string
+	}{
+		{
+			name: "emphasized line",
+			src:  "![tori](/images/tori.png)\n*The `default` theme*\n",
+			want: `<figure><img src="/images/tori.png" alt="tori"><figcaption>The <code>default</code> theme</figcaption></figure>`,
+		},
+		{
+			name: "underscore emphasis",
+			src:  "![tori](/images/tori.png)\n_Tokyo Night_\n",
+			want: `<figure><img src="/images/tori.png" alt="tori"><figcaption>Tokyo Night</figcaption></figure>`,
+		},
+		{
+			name: "title",
+			src:  `![tori](/images/tori.png "Rosé <Pine>")`,
+			want: `<figure><img src="/images/tori.png" alt="tori"><figcaption>Rosé &lt;Pine&gt;</figcaption></figure>`,
+		},
+		{
+			name: "plain image",
+			src:  "![tori](/images/tori.png)\n",
+			want: `<p><img src="/images/tori.png" alt="tori"></p>`,
+		},
+		{
+			name: "inline image",
+			src:  "See ![tori](/images/tori.png)\n*not a caption*\n",
+			want: `<p>See <img src="/images/tori.png" alt="tori">`,
+		},
+		{
+			name: "emphasis on the same line",
+			src:  "![tori](/images/tori.png) *not a caption*\n",
+			want: `<p><img src="/images/tori.png" alt="tori"> <em>not a caption</em></p>`,
+		},
+		{
+			name: "strong is not a caption",
+			src:  "![tori](/images/tori.png)\n**bold**\n",
+			want: `<p><img src="/images/tori.png" alt="tori">`,
+		},
+	} {
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(tt.src), &buf); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%s: missing %s\ngot: %s", tt.name, tt.want, buf.String())
+		}
+	}
+}
+
+func TestGallery(t *testing.T) {
+	src := `Before
+
+:::gallery
+![one](/images/one.png)
+*First*
+![two](/images/two.png "Second")
+
+![three](/images/three.png)
+:::
+
+After
+`
+	var buf bytes.Buffer
+	if err := newMarkdown().Convert([]byte(src), &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<p>Before</p>\n<div class=\"gallery\">\n",
+		`<figure><a href="/images/one.png"><img src="/images/one.png" alt="one"></a><figcaption>First</figcaption></figure>`,
+		`<figure><a href="/images/two.png"><img src="/images/two.png" alt="two"></a><figcaption>Second</figcaption></figure>`,
+		`<figure><a href="/images/three.png"><img src="/images/three.png" alt="three"></a></figure>`,
+		"</div>\n<p>After</p>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s\ngot: %s", want, out)
+		}
+	}
+	if strings.Contains(out, ":::") {
+		t.Errorf("fence markers should not be rendered:\n%s", out)
+	}
+}
+
+func TestGallerySizes(t *testing.T) {
+	images := &imageSet{}
+	images.set(map[string]imageInfo{
+		"/images/one.png": {Width: 1200, Height: 800, Variants: []imageVariant{{File: "a-480.png", Width: 480}}},
+	})
+	md := newMarkdown(&imageExtension{images: images})
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(":::gallery\n![one](/images/one.png)\n:::\n"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `sizes="`+gallerySizes+`"`) {
+		t.Errorf("gallery image should use gallery sizes:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,182 @@
+package blog
+
+import (
+	"strconv"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.abhg.dev/goldmark/frontmatter"
+)
+
+// Footnotes keep goldmark's ids, fn:1 and fnref:1. Generated heading ids
+// never contain a colon, so they can't clash with the anchor extension's
+// heading links. When several documents share a page, like changelog
+// entries, each sets an id prefix through footnotePrefixKey.
+//
+// With sidenotes: true in the frontmatter, footnotes are rendered next to
+// their reference instead of at the end: as margin notes on wide screens
+// and as notes toggled by tapping the number on narrow ones. Footnotes
+// with more than paragraphs in them stay at the end.
+
+var footnotePrefixKey = parser.NewContextKey()
+
+var kindSidenote = ast.NewNodeKind("Sidenote")
+
+type sidenoteNode struct {
+	ast.BaseInline
+	Index int
+}
+
+func (n *sidenoteNode) Kind() ast.NodeKind { return kindSidenote }
+
+func (n *sidenoteNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Index": strconv.Itoa(n.Index)}, nil)
+}
+
+type footnoteExtension struct{}
+
+func (footnoteExtension) Extend(m goldmark.Markdown) {
+	extension.NewFootnote(extension.WithFootnoteIDPrefixFunction(footnotePrefix)).Extend(m)
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		// after goldmark's footnote transformer has built the list
+		util.Prioritized(sidenoteTransformer{}, 1000),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(sidenoteRenderer{}, 500),
+	))
+}
+
+func footnotePrefix(n ast.Node) []byte {
+	if doc := n.OwnerDocument(); doc != nil {
+		if prefix, ok := doc.Meta()["footnote-prefix"].(string); ok {
+			return []byte(prefix)
+		}
+	}
+	return nil
+}
+
+func sidenotesEnabled(pc parser.Context) bool {
+	fm := frontmatter.Get(pc)
+	if fm == nil {
+		return false
+	}
+	var meta struct {
+		Sidenotes bool `yaml:"sidenotes"`
+	}
+	return fm.Decode(&meta) == nil && meta.Sidenotes
+}
+
+type sidenoteTransformer struct{}
+
+func (sidenoteTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if prefix, ok := pc.Get(footnotePrefixKey).(string); ok {
+		doc.AddMeta("footnote-prefix", prefix)
+	}
+	if !sidenotesEnabled(pc) {
+		return
+	}
+
+	var list *extast.FootnoteList
+	var links []*extast.FootnoteLink
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *extast.FootnoteList:
+			list = n
+			return ast.WalkSkipChildren, nil
+		case *extast.FootnoteLink:
+			links = append(links, n)
+		}
+		return ast.WalkContinue, nil
+	})
+	if list == nil {
+		return
+	}
+
+	notes := make(map[int]*extast.Footnote)
+	for c := list.FirstChild(); c != nil; c = c.NextSibling() {
+		if fn, ok := c.(*extast.Footnote); ok && onlyParagraphs(fn) {
+			notes[fn.Index] = fn
+		}
+	}
+
+	for _, link := range links {
+		fn, ok := notes[link.Index]
+		if !ok {
+			continue
+		}
+		// later references to the same note link to this one
+		delete(notes, link.Index)
+
+		side := &sidenoteNode{Index: link.Index}
+		for p := fn.FirstChild(); p != nil; p = p.NextSibling() {
+			if p != fn.FirstChild() {
+				br := ast.NewText()
+				br.SetHardLineBreak(true)
+				side.AppendChild(side, br)
+			}
+			for c := p.FirstChild(); c != nil; {
+				next := c.NextSibling()
+				if _, backlink := c.(*extast.FootnoteBacklink); !backlink {
+					side.AppendChild(side, c)
+				}
+				c = next
+			}
+		}
+		link.Parent().ReplaceChild(link.Parent(), link, side)
+		list.RemoveChild(list, fn)
+	}
+
+	if !list.HasChildren() {
+		list.Parent().RemoveChild(list.Parent(), list)
+		return
+	}
+	// keep the numbering of the notes left at the end
+	for c := list.FirstChild(); c != nil; c = c.NextSibling() {
+		if fn, ok := c.(*extast.Footnote); ok {
+			fn.SetAttributeString("value", []byte(strconv.Itoa(fn.Index)))
+		}
+	}
+}
+
+func onlyParagraphs(n ast.Node) bool {
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if c.Kind() != ast.KindParagraph {
+			return false
+		}
+	}
+	return true
+}
+
+type sidenoteRenderer struct{}
+
+func (sidenoteRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindSidenote, renderSidenote)
+}
+
+// renderSidenote writes the note after a label for a hidden checkbox, so
+// narrow screens can show and hide it without any JavaScript.
+func renderSidenote(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		w.WriteString("</span>")
+		return ast.WalkContinue, nil
+	}
+	n := node.(*sidenoteNode)
+	prefix := string(footnotePrefix(n))
+	i := strconv.Itoa(n.Index)
+	toggle := prefix + "sn:" + i
+
+	w.WriteString(`<label for="` + toggle + `" class="sidenote-ref" id="` + prefix + `fnref:` + i + `" role="doc-noteref">` + i + `</label>`)
+	w.WriteString(`<input type="checkbox" id="` + toggle + `" class="sidenote-toggle">`)
+	w.WriteString(`<span class="sidenote" id="` + prefix + `fn:` + i + `" role="doc-footnote">`)
+	w.WriteString(`<span class="sidenote-number">` + i + `</span> `)
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,126 @@
+package blog
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	idAttr   = regexp.MustCompile(`id="([^"]+)"`)
+	hashHref = regexp.MustCompile(`href="#([^"]+)"`)
+)
+
+// checkIDs fails if an id repeats or an in-page link has no target.
+func checkIDs(t *testing.T, html string) {
+	t.Helper()
+	ids := make(map[string]bool)
+	for _, m := range idAttr.FindAllStringSubmatch(html, -1) {
+		if ids[m[1]] {
+			t.Errorf("duplicate id %q", m[1])
+		}
+		ids[m[1]] = true
+	}
+	for _, m := range hashHref.FindAllStringSubmatch(html, -1) {
+		if !ids[m[1]] {
+			t.Errorf("link to #%s has no target", m[1])
+		}
+	}
+}
+
+func TestFootnotes(t *testing.T) {
+	out := renderMarkdown(t, `## fn 1
+
+Text with a note.[^1] And another.[^note]
+
+## Footnotes
+
+[^1]: The first note.
+[^note]: The second note.
+`)
+
+	for _, want := range []string{
+		`<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>`,
+		`<div class="footnotes" role="doc-endnotes">`,
+		`<li id="fn:2">`,
+		`<a href="#fnref:2" class="footnote-backref" role="doc-backlink">`,
+		`<h2 id="fn-1">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s\ngot: %s", want, out)
+		}
+	}
+	checkIDs(t, out)
+}
+
+func TestSidenotes(t *testing.T) {
+	out := renderMarkdown(t, `---
+title: Notes
+sidenotes: true
+---
+Text.[^1] A list.[^list] Again.[^1]
+
+[^1]: A *short* note.
+
+    With a second paragraph.
+[^list]: Items:
+
+    - one
+`)
+
+	for _, want := range []string{
+		`<label for="sn:1" class="sidenote-ref" id="fnref:1" role="doc-noteref">1</label><input type="checkbox" id="sn:1" class="sidenote-toggle">`,
+		"<span class=\"sidenote\" id=\"fn:1\" role=\"doc-footnote\"><span class=\"sidenote-number\">1</span> A <em>short</em> note.<br>\nWith a second paragraph.</span>",
+		// a note with a list stays at the end, keeping its number
+		`<sup id="fnref:2"><a href="#fn:2"`,
+		`<li id="fn:2" value="2">`,
+		// a second reference points at the sidenote
+		`<sup id="fnref1:1"><a href="#fn:1"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s\ngot: %s", want, out)
+		}
+	}
+	if strings.Contains(out, `<li id="fn:1"`) || strings.Contains(out, "footnote-backref\" role=\"doc-backlink\">&#x21a9;&#xfe0e;</a></span>") {
+		t.Errorf("sidenote should not also be listed at the end:\n%s", out)
+	}
+	checkIDs(t, out)
+
+	out = renderMarkdown(t, "---\ntitle: Notes\nsidenotes: true\n---\nText.[^1]\n\n[^1]: Note.\n")
+	if strings.Contains(out, "footnotes") {
+		t.Errorf("empty footnote list should be dropped:\n%s", out)
+	}
+}
+
+func TestChangelogFootnotePrefix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "changelog.yaml")
+	os.WriteFile(path, []byte(`- version: v1.1.0
+  date: 2026-02-01
+  notes: |
+    Faster.[^1]
+
+    [^1]: On Linux.
+- version: v1.0.0
+  date: 2026-01-01
+  notes: |
+    First.[^1]
+
+    [^1]: Mostly.
+`), 0o644)
+
+	entries, err := loadChangelog(path, newMarkdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var page strings.Builder
+	for _, e := range entries {
+		page.WriteString(string(e.Notes))
+	}
+	if !strings.Contains(page.String(), `<li id="v1-1-0-fn:1">`) {
+		t.Errorf("footnote ids should be prefixed by the entry:\n%s", page.String())
+	}
+	checkIDs(t, page.String())
+}
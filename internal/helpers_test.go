@@ -1,12 +1,15 @@
 package blog
 
 import (
+	"bytes"
 	"database/sql"
 	"fmt"
 	"html/template"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/yuin/goldmark"
 )
 
 // testApp creates a fully functional *App with in-memory SQLite, real templates,
@@ -25,15 +28,18 @@ func testApp(t *testing.T) *App {
 
 	app := &App{
 		cfg: Config{
-			BaseURL:     "http://localhost:8080",
-			ContentDir:  "content",
-			AdminAPIKey: "test-key",
+			BaseURL:       "http://localhost:8080",
+			ContentDir:    "content",
+			ImageCacheDir: t.TempDir(),
+			AdminAPIKey:   "test-key",
 		},
-		db:        db,
-		md:        md,
-		chromaCSS: chromaCSS,
-		tmpls:     tmpls,
-		limiter:   newRateLimiter(),
+		db:             db,
+		md:             md,
+		images:         &imageSet{},
+		chromaCSS:      chromaCSS,
+		tmpls:          tmpls,
+		limiter:        newRateLimiter(),
+		suggestLimiter: newSuggestLimiter(),
 		posts: []Post{
 			{
 				Title:       "First Post",
@@ -53,8 +59,8 @@ func testApp(t *testing.T) *App {
 			},
 		},
 		pages: []Page{
-			{Title: "Uses", Slug: "uses", Body: "<p>My tools</p>"},
-			{Title: "Now", Slug: "now", Body: "<p>What I'm doing now</p>"},
+			{Title: "Uses", Slug: "uses", Nav: true, NavOrder: 1, Body: "<p>My tools</p>"},
+			{Title: "Now", Slug: "now", Nav: true, NavOrder: 2, Body: "<p>What I'm doing now</p>"},
 		},
 		projects: []Project{
 			{
@@ -73,6 +79,7 @@ func testApp(t *testing.T) *App {
 			},
 		},
 	}
+	app.nav = buildNav(app.pages)
 
 	return app
 }
@@ -84,7 +91,7 @@ func testTemplates(t *testing.T) map[string]*template.Template {
 	funcMap := template.FuncMap{
 		"formatDate": func(t time.Time) string { return t.Format("January 2, 2006") },
 		"shortDate":  func(t time.Time) string { return t.Format("2006-01-02") },
-		"isLocal": func() bool { return true },
+		"isLocal":    func() bool { return true },
 		"readTime": func(d time.Duration) string {
 			m := int(d.Minutes())
 			if m < 1 {
@@ -95,7 +102,7 @@ func testTemplates(t *testing.T) map[string]*template.Template {
 	}
 
 	base := filepath.Join("..", "templates")
-	names := []string{"home", "post", "post_list", "page", "project", "project_list", "subscribe", "search", "404"}
+	names := []string{"archive", "changelog", "home", "now", "now_archive", "post", "post_history", "post_list", "page", "project", "project_list", "project_page", "series", "tag", "tag_list", "subscribe", "search", "404", "410"}
 	tmpls := make(map[string]*template.Template, len(names))
 	for _, name := range names {
 		tmpl, err := template.New("base.html").Funcs(funcMap).ParseFiles(
@@ -123,6 +130,27 @@ func testDB(t *testing.T) *sql.DB {
 	return db
 }
 
+// renderMarkdown converts src with the markdown setup used for content.
+func renderMarkdown(t *testing.T, src string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := newMarkdown().Convert([]byte(src), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// postRenderIssues loads the posts in dir and returns the render issues
+// found while loading them.
+func postRenderIssues(t *testing.T, dir string, md goldmark.Markdown) []lintIssue {
+	t.Helper()
+	_, issues, err := loadAllPosts(dir, md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issues.Render
+}
+
 // seedComments inserts test comments into the database.
 func seedComments(t *testing.T, db *sql.DB, comments []Comment) {
 	t.Helper()
@@ -0,0 +1,141 @@
+package blog
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// revision is a commit that touched a post's file.
+type revision struct {
+	Hash    string
+	Date    time.Time
+	Subject string
+}
+
+type gitFile struct {
+	Last    time.Time
+	Commits int
+}
+
+// gitFileDates returns, for every file under dir, the date of the last commit
+// touching it and how many commits did. Paths are relative to dir with
+// forward slashes. It returns nil if dir isn't in a git checkout.
+func gitFileDates(dir string) map[string]gitFile {
+	out, err := exec.Command("git", "-C", dir, "log", "--format=%x00%cI", "--name-only", "--relative", "--", ".").Output()
+	if err != nil {
+		return nil
+	}
+
+	files := make(map[string]gitFile)
+	var date time.Time
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if c, ok := strings.CutPrefix(line, "\x00"); ok {
+			date, _ = time.Parse(time.RFC3339, c)
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		f := files[line]
+		if f.Commits == 0 {
+			// git log is newest first
+			f.Last = date
+		}
+		f.Commits++
+		files[line] = f
+	}
+	return files
+}
+
+// applyGitDates sets Updated on posts without an updated: key from the last
+// commit touching their file. A file with a single commit hasn't been
+// edited, only added or published, so it keeps a zero Updated.
+func applyGitDates(dir string, posts []Post) {
+	files := gitFileDates(dir)
+	if files == nil {
+		return
+	}
+	for i := range posts {
+		p := &posts[i]
+		if !p.Updated.IsZero() {
+			continue
+		}
+		rel, err := filepath.Rel(dir, p.Path)
+		if err != nil {
+			continue
+		}
+		f := files[filepath.ToSlash(rel)]
+		if f.Commits > 1 && f.Last.After(p.Date.AddDate(0, 0, 1)) {
+			p.Updated = f.Last.UTC().Truncate(24 * time.Hour)
+		}
+	}
+}
+
+// lastModified is when a post's content last changed.
+func (p Post) lastModified() time.Time {
+	if p.Updated.After(p.Date) {
+		return p.Updated
+	}
+	return p.Date
+}
+
+// gitRevisions lists the commits that touched path since the post was
+// published, newest first. Renames are followed, but drafts and work in
+// progress from before since stay out of the public history.
+func gitRevisions(path string, since time.Time) []revision {
+	args := []string{"-C", filepath.Dir(path), "log", "--follow", "--format=%h%x00%cI%x00%s"}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+	out, err := exec.Command("git", append(args, "--", filepath.Base(path))...).Output()
+	if err != nil {
+		return nil
+	}
+
+	var revs []revision
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.SplitN(line, "\x00", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, parts[1])
+		revs = append(revs, revision{Hash: parts[0], Date: date, Subject: parts[2]})
+	}
+	return revs
+}
+
+func (app *App) handlePostHistory(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	app.mu.RLock()
+	post, ok := findPost(app.posts, slug)
+	revs, cached := app.revisions[slug]
+	app.mu.RUnlock()
+
+	if !ok || !app.cfg.RevisionHistory || (!app.cfg.isLocal() && post.Private) {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	if !cached {
+		revs = gitRevisions(post.Path, post.Date)
+		app.mu.Lock()
+		if app.revisions != nil {
+			app.revisions[slug] = revs
+		}
+		app.mu.Unlock()
+	}
+
+	app.render(w, "post_history", map[string]any{
+		"Post":      post,
+		"Revisions": revs,
+		"BaseURL":   app.cfg.BaseURL,
+	})
+}
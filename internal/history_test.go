@@ -0,0 +1,134 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitCommit commits everything in dir with the given commit date.
+func gitCommit(t *testing.T, dir, msg, date string) {
+	t.Helper()
+
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", msg},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date, "GIT_AUTHOR_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestApplyGitDates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-edited.md":    "---\ntitle: Edited\ndate: 2026-01-01\n---\nv1\n",
+		"posts/2026-01-01-untouched.md": "---\ntitle: Untouched\ndate: 2026-01-01\n---\n",
+		"posts/2026-01-01-explicit.md":  "---\ntitle: Explicit\ndate: 2026-01-01\nupdated: 2026-02-01\n---\nv1\n",
+	})
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	gitCommit(t, dir, "Add posts", "2026-01-01T12:00:00Z")
+	os.WriteFile(filepath.Join(dir, "posts", "2026-01-01-edited.md"), []byte("---\ntitle: Edited\ndate: 2026-01-01\n---\nv2\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "posts", "2026-01-01-explicit.md"), []byte("---\ntitle: Explicit\ndate: 2026-01-01\nupdated: 2026-02-01\n---\nv2\n"), 0644)
+	gitCommit(t, dir, "Fix typo", "2026-03-10T08:00:00Z")
+
+	posts, _, err := loadAllPosts(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadAllPosts: %v", err)
+	}
+	applyGitDates(dir, posts)
+
+	want := map[string]string{
+		"edited":    "2026-03-10",
+		"untouched": "0001-01-01",
+		"explicit":  "2026-02-01",
+	}
+	for _, p := range posts {
+		if got := p.Updated.Format("2006-01-02"); got != want[p.Slug] {
+			t.Errorf("%s: Updated = %s, want %s", p.Slug, got, want[p.Slug])
+		}
+	}
+
+	edited, _ := findPost(posts, "edited")
+	revs := gitRevisions(edited.Path, edited.Date)
+	if len(revs) != 2 || revs[0].Subject != "Fix typo" {
+		t.Errorf("revisions = %+v, want Fix typo then Add posts", revs)
+	}
+}
+
+func TestGitRevisionsSincePublish(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "posts", "2026-01-01-hello.md")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	os.WriteFile(path, []byte("---\ntitle: Hello\ndate: 2026-01-01\n---\nwip\n"), 0644)
+	gitCommit(t, dir, "WIP: rough notes", "2025-12-20T09:00:00Z")
+	os.WriteFile(path, []byte("---\ntitle: Hello\ndate: 2026-01-01\n---\nDone.\n"), 0644)
+	gitCommit(t, dir, "Publish hello", "2026-01-01T10:00:00Z")
+
+	revs := gitRevisions(path, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(revs) != 1 || revs[0].Subject != "Publish hello" {
+		t.Errorf("revisions = %+v, want only the commit since publish", revs)
+	}
+}
+
+func TestHandlePostUpdated(t *testing.T) {
+	app := testApp(t)
+	app.posts[0].Updated = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	req := httptest.NewRequest("GET", "/posts/first-post", nil)
+	req.SetPathValue("slug", "first-post")
+	w := httptest.NewRecorder()
+	app.handlePost(w, req)
+
+	if got := w.Header().Get("Last-Modified"); got != "Tue, 10 Mar 2026 00:00:00 GMT" {
+		t.Errorf("Last-Modified = %q", got)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Updated <time class=\"dt-updated\" datetime=\"2026-03-10\">") {
+		t.Error("post should show its updated date")
+	}
+	if strings.Contains(body, "/posts/first-post/history") {
+		t.Error("history link should be hidden unless REVISION_HISTORY is set")
+	}
+}
+
+func TestHandlePostHistory(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/posts/first-post/history", nil)
+	req.SetPathValue("slug", "first-post")
+	w := httptest.NewRecorder()
+	app.handlePostHistory(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+
+	app.cfg.RevisionHistory = true
+	app.revisions = map[string][]revision{
+		"first-post": {{Hash: "abc1234", Date: time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC), Subject: "Fix typo"}},
+	}
+	w = httptest.NewRecorder()
+	app.handlePostHistory(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("enabled: status = %d, want 200", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Fix typo") {
+		t.Error("history should list commits")
+	}
+}
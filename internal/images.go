@@ -0,0 +1,399 @@
+package blog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Content images are resized to these widths, skipping any at or above
+// the original's. The column is 42rem, so 960 covers it on 2x screens.
+var imageWidths = []int{480, 960, 1440}
+
+// imageSizes matches the content column in style.css, and gallerySizes
+// its grid (one column on phones, two above).
+const (
+	imageSizes   = "(max-width: 42rem) 100vw, 42rem"
+	gallerySizes = "(max-width: 600px) 100vw, (max-width: 42rem) 50vw, 21rem"
+)
+
+const imageJPEGQuality = 82
+
+// imageVariant is one resized copy of a content image in the cache dir.
+type imageVariant struct {
+	File  string
+	Width int
+}
+
+// imageInfo describes a content image and its variants. WebP is only
+// generated for PNG sources: the encoder is lossless, which beats PNG on
+// screenshots but not JPEG on photos.
+type imageInfo struct {
+	Hash          string
+	Width, Height int
+	Variants      []imageVariant // same format as the original
+	WebP          []imageVariant
+}
+
+func (info imageInfo) files() []string {
+	var files []string
+	for _, v := range append(info.Variants, info.WebP...) {
+		files = append(files, v.File)
+	}
+	return files
+}
+
+// imageSet holds the processed images, keyed by their /images/ URL. The
+// markdown renderer reads it while posts are parsed during reload.
+type imageSet struct {
+	mu     sync.RWMutex
+	images map[string]imageInfo
+	files  map[string]bool
+}
+
+func (s *imageSet) lookup(src string) (imageInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	info, ok := s.images[src]
+	return info, ok
+}
+
+func (s *imageSet) has(file string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.files[file]
+}
+
+func (s *imageSet) set(images map[string]imageInfo) {
+	files := make(map[string]bool)
+	for _, info := range images {
+		for _, f := range info.files() {
+			files[f] = true
+		}
+	}
+	s.mu.Lock()
+	s.images = images
+	s.files = files
+	s.mu.Unlock()
+}
+
+// processImages generates variants for every PNG and JPEG under
+// content/images. Variants are named by content hash, so only new or
+// changed images are resized; files no longer needed are removed.
+// An image that fails to process is logged and served as-is.
+func processImages(contentDir, cacheDir string) (map[string]imageInfo, error) {
+	root := filepath.Join(contentDir, "images")
+	images := make(map[string]imageInfo)
+
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return images, nil
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+			return nil
+		}
+		info, err := processImage(path, cacheDir)
+		if err != nil {
+			log.Printf("warning: image %s: %v", path, err)
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		images["/images/"+filepath.ToSlash(rel)] = info
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pruneImageCache(cacheDir, images)
+	return images, nil
+}
+
+func processImage(path, cacheDir string) (imageInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return imageInfo{}, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:8])
+	manifest := filepath.Join(cacheDir, hash+".json")
+	if info, ok := cachedImage(manifest, cacheDir); ok {
+		return info, nil
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return imageInfo{}, err
+	}
+	b := src.Bounds()
+	info := imageInfo{Hash: hash, Width: b.Dx(), Height: b.Dy()}
+
+	// a variant that comes out larger than the original isn't worth
+	// serving; resampling often does that to flat-colour screenshots
+	keep := func(file string, width int) (imageVariant, bool, error) {
+		out, err := encodeVariant(src, width, filepath.Ext(file))
+		if err != nil || len(out) >= len(data) {
+			return imageVariant{}, false, err
+		}
+		if err := writeFileAtomic(filepath.Join(cacheDir, file), out); err != nil {
+			return imageVariant{}, false, err
+		}
+		return imageVariant{File: file, Width: width}, true, nil
+	}
+
+	var sizes []int
+	for _, w := range imageWidths {
+		if w < info.Width {
+			sizes = append(sizes, w)
+		}
+	}
+	ext := ".jpg"
+	if format == "png" {
+		ext = ".png"
+	}
+	for _, w := range sizes {
+		v, ok, err := keep(fmt.Sprintf("%s-%d%s", hash, w, ext), w)
+		if err != nil {
+			return imageInfo{}, err
+		}
+		if ok {
+			info.Variants = append(info.Variants, v)
+		}
+	}
+	if format == "png" {
+		for _, w := range append(sizes, info.Width) {
+			v, ok, err := keep(fmt.Sprintf("%s-%d.webp", hash, w), w)
+			if err != nil {
+				return imageInfo{}, err
+			}
+			if ok {
+				info.WebP = append(info.WebP, v)
+			}
+		}
+	}
+
+	out, err := json.Marshal(info)
+	if err != nil {
+		return imageInfo{}, err
+	}
+	return info, writeFileAtomic(manifest, out)
+}
+
+// cachedImage reads a manifest written by an earlier reload, provided
+// every variant it lists is still on disk.
+func cachedImage(manifest, cacheDir string) (imageInfo, bool) {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return imageInfo{}, false
+	}
+	var info imageInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return imageInfo{}, false
+	}
+	for _, v := range info.files() {
+		if _, err := os.Stat(filepath.Join(cacheDir, v)); err != nil {
+			return imageInfo{}, false
+		}
+	}
+	return info, true
+}
+
+// encodeVariant scales src to width and encodes it in the format named
+// by ext.
+func encodeVariant(src image.Image, width int, ext string) ([]byte, error) {
+	b := src.Bounds()
+	height := max(b.Dy()*width/b.Dx(), 1)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if width == b.Dx() {
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	} else {
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch ext {
+	case ".png":
+		err = png.Encode(&buf, dst)
+	case ".webp":
+		err = nativewebp.Encode(&buf, dst, nil)
+	default:
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: imageJPEGQuality})
+	}
+	return buf.Bytes(), err
+}
+
+// writeFileAtomic goes through a temp file so a crash never leaves a
+// truncated file that later reloads would mistake for a cached one.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func pruneImageCache(cacheDir string, images map[string]imageInfo) {
+	keep := make(map[string]bool)
+	for _, info := range images {
+		keep[info.Hash+".json"] = true
+		for _, f := range info.files() {
+			keep[f] = true
+		}
+	}
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() && !keep[e.Name()] {
+			os.Remove(filepath.Join(cacheDir, e.Name()))
+		}
+	}
+}
+
+func (app *App) handleImageVariant(w http.ResponseWriter, r *http.Request) {
+	file := r.PathValue("file")
+	if !app.images.has(file) {
+		app.renderNotFound(w, r)
+		return
+	}
+	// variants are named by content hash, so they never change
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(w, r, filepath.Join(app.cfg.ImageCacheDir, file))
+}
+
+// imageExtension renders markdown images from content/images as
+// responsive <img srcset> (or <picture> with WebP) with intrinsic
+// dimensions, and lazy-loads every image.
+type imageExtension struct {
+	images *imageSet
+}
+
+func (e *imageExtension) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&imageRenderer{images: e.images}, 500),
+	))
+}
+
+type imageRenderer struct {
+	images *imageSet
+}
+
+func (r *imageRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, r.renderImage)
+}
+
+func (r *imageRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	src := string(n.Destination)
+	info, ok := r.images.lookup(src)
+	sizes := imageSizes
+	if inGallery(n) {
+		sizes = gallerySizes
+	}
+
+	if ok && len(info.WebP) > 0 {
+		w.WriteString(`<picture><source type="image/webp" srcset="`)
+		w.WriteString(srcset(info.WebP, "", 0))
+		w.WriteString(`" sizes="` + sizes + `">`)
+	}
+
+	w.WriteString(`<img src="`)
+	w.Write(util.EscapeHTML(util.URLEscape(n.Destination, true)))
+	w.WriteString(`" alt="`)
+	w.Write(altText(source, n))
+	w.WriteByte('"')
+	if n.Title != nil {
+		w.WriteString(` title="`)
+		w.Write(util.EscapeHTML(n.Title))
+		w.WriteByte('"')
+	}
+	if ok {
+		if len(info.Variants) > 0 {
+			w.WriteString(` srcset="`)
+			w.WriteString(srcset(info.Variants, src, info.Width))
+			w.WriteString(`" sizes="` + sizes + `"`)
+		}
+		w.WriteString(` width="` + strconv.Itoa(info.Width) + `" height="` + strconv.Itoa(info.Height) + `"`)
+	}
+	w.WriteString(` loading="lazy" decoding="async">`)
+
+	if ok && len(info.WebP) > 0 {
+		w.WriteString(`</picture>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func inGallery(n ast.Node) bool {
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == kindGallery {
+			return true
+		}
+	}
+	return false
+}
+
+// srcset lists variants by width, ending with the original when given.
+func srcset(variants []imageVariant, original string, width int) string {
+	var parts []string
+	for _, v := range variants {
+		parts = append(parts, "/img/"+v.File+" "+strconv.Itoa(v.Width)+"w")
+	}
+	if original != "" {
+		parts = append(parts, original+" "+strconv.Itoa(width)+"w")
+	}
+	return string(util.EscapeHTML([]byte(strings.Join(parts, ", "))))
+}
+
+// altText flattens an image's inline children into escaped attribute text.
+func altText(source []byte, n ast.Node) []byte {
+	var buf bytes.Buffer
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			buf.Write(util.EscapeHTML(c.Segment.Value(source)))
+			if c.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			// typographer output, already entity-encoded
+			buf.Write(c.Value)
+		default:
+			buf.Write(altText(source, c))
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,205 @@
+package blog
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeNoisePNG writes a PNG that compresses badly, so every variant is
+// smaller than the original and gets kept.
+func writeNoisePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng := rand.New(rand.NewSource(1))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessImages(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	writeNoisePNG(t, filepath.Join(dir, "images", "shots", "wide.png"), 1000, 500)
+	writeNoisePNG(t, filepath.Join(dir, "images", "small.png"), 300, 200)
+	os.WriteFile(filepath.Join(dir, "images", "notes.txt"), []byte("not an image"), 0o644)
+
+	images, err := processImages(dir, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("got %d images, want 2: %v", len(images), images)
+	}
+
+	wide := images["/images/shots/wide.png"]
+	if wide.Width != 1000 || wide.Height != 500 {
+		t.Errorf("wide: size = %dx%d, want 1000x500", wide.Width, wide.Height)
+	}
+	var got []int
+	for _, v := range wide.Variants {
+		got = append(got, v.Width)
+		if !strings.HasSuffix(v.File, ".png") {
+			t.Errorf("variant %s should keep the source format", v.File)
+		}
+	}
+	if len(got) != 2 || got[0] != 480 || got[1] != 960 {
+		t.Errorf("wide: variant widths = %v, want [480 960]", got)
+	}
+	// full-size lossless WebP of noise is no smaller than the PNG
+	if len(wide.WebP) != 2 || wide.WebP[0].Width != 480 || wide.WebP[1].Width != 960 {
+		t.Errorf("wide: webp = %v, want 480 and 960", wide.WebP)
+	}
+	for _, f := range wide.files() {
+		if _, err := os.Stat(filepath.Join(cache, f)); err != nil {
+			t.Errorf("variant %s not written: %v", f, err)
+		}
+	}
+
+	if small := images["/images/small.png"]; len(small.Variants) != 0 {
+		t.Errorf("small: variants = %v, want none below 480px", small.Variants)
+	}
+
+	// a removed image takes its variants with it
+	os.Remove(filepath.Join(dir, "images", "shots", "wide.png"))
+	if _, err := processImages(dir, cache); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range append(wide.files(), wide.Hash+".json") {
+		if _, err := os.Stat(filepath.Join(cache, f)); !os.IsNotExist(err) {
+			t.Errorf("%s should be pruned", f)
+		}
+	}
+}
+
+func TestProcessImagesCached(t *testing.T) {
+	dir := t.TempDir()
+	cache := t.TempDir()
+	writeNoisePNG(t, filepath.Join(dir, "images", "a.png"), 600, 300)
+
+	first, err := processImages(dir, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cache, first["/images/a.png"].Variants[0].File)
+	before, _ := os.Stat(file)
+
+	again, err := processImages(dir, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(file)
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("unchanged image should not be re-encoded")
+	}
+	if len(again["/images/a.png"].Variants) != len(first["/images/a.png"].Variants) {
+		t.Errorf("cached info = %v, want %v", again["/images/a.png"], first["/images/a.png"])
+	}
+}
+
+func TestImageRenderer(t *testing.T) {
+	images := &imageSet{}
+	images.set(map[string]imageInfo{
+		"/images/shot.png": {
+			Width: 1200, Height: 800,
+			Variants: []imageVariant{{File: "abc-480.png", Width: 480}},
+			WebP:     []imageVariant{{File: "abc-480.webp", Width: 480}, {File: "abc-1200.webp", Width: 1200}},
+		},
+		"/images/photo.jpg": {Width: 300, Height: 200},
+	})
+	md := newMarkdown(&imageExtension{images: images})
+
+	for _, tt := range []struct {
+		name, src string
+		want      []string
+		not       []string
+	}{
+		{
+			name: "png with variants",
+			src:  `See ![tori "dashboard"](/images/shot.png "Tokyo Night")`,
+			want: []string{
+				`<picture><source type="image/webp" srcset="/img/abc-480.webp 480w, /img/abc-1200.webp 1200w" sizes="` + imageSizes + `">`,
+				`<img src="/images/shot.png" alt="tori &ldquo;dashboard&rdquo;" title="Tokyo Night"`,
+				`srcset="/img/abc-480.png 480w, /images/shot.png 1200w"`,
+				`width="1200" height="800" loading="lazy" decoding="async"></picture>`,
+			},
+		},
+		{
+			name: "small jpeg",
+			src:  `![photo](/images/photo.jpg)`,
+			want: []string{`<img src="/images/photo.jpg" alt="photo" width="300" height="200" loading="lazy"`},
+			not:  []string{"<picture>", "srcset"},
+		},
+		{
+			name: "external",
+			src:  `![badge](https://example.com/badge.svg)`,
+			want: []string{`<img src="https://example.com/badge.svg" alt="badge" loading="lazy" decoding="async">`},
+		},
+	} {
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(tt.src), &buf); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: missing %s\ngot: %s", tt.name, want, out)
+			}
+		}
+		for _, not := range tt.not {
+			if strings.Contains(out, not) {
+				t.Errorf("%s: unexpected %s\ngot: %s", tt.name, not, out)
+			}
+		}
+	}
+}
+
+func TestHandleImageVariant(t *testing.T) {
+	app := testApp(t)
+	os.WriteFile(filepath.Join(app.cfg.ImageCacheDir, "abc-480.png"), []byte("\x89PNG fake"), 0o644)
+	os.WriteFile(filepath.Join(app.cfg.ImageCacheDir, "abc.json"), []byte("{}"), 0o644)
+	app.images.set(map[string]imageInfo{
+		"/images/shot.png": {Hash: "abc", Variants: []imageVariant{{File: "abc-480.png", Width: 480}}},
+	})
+
+	req := httptest.NewRequest("GET", "/img/abc-480.png", nil)
+	req.SetPathValue("file", "abc-480.png")
+	w := httptest.NewRecorder()
+	app.handleImageVariant(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if !strings.Contains(w.Header().Get("Cache-Control"), "immutable") {
+		t.Errorf("Cache-Control = %q, want immutable", w.Header().Get("Cache-Control"))
+	}
+
+	for _, file := range []string{"abc.json", "missing-480.png"} {
+		req = httptest.NewRequest("GET", "/img/"+file, nil)
+		req.SetPathValue("file", file)
+		w = httptest.NewRecorder()
+		app.handleImageVariant(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", file, w.Code)
+		}
+	}
+}
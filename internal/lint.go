@@ -0,0 +1,584 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/frontmatter"
+	"gopkg.in/yaml.v3"
+)
+
+// lintIssue is a content problem reported by blog lint.
+type lintIssue struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (i lintIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Msg)
+	}
+	return i.File + ": " + i.Msg
+}
+
+// lintField is a frontmatter value and the file line its key is on.
+type lintField struct {
+	Value *yaml.Node
+	Line  int
+}
+
+// lintDoc is a content file parsed for linting. Unlike parsePost it keeps
+// the AST and frontmatter positions so problems can point at a line.
+type lintDoc struct {
+	Path    string
+	Kind    string // post, project, project-page, page or tag
+	Slug    string
+	Private bool
+	Src     []byte
+	Doc     ast.Node
+	Fields  map[string]lintField
+	HasMeta bool
+}
+
+func (d *lintDoc) str(key string) string {
+	if f, ok := d.Fields[key]; ok && f.Value.Kind == yaml.ScalarNode {
+		return f.Value.Value
+	}
+	return ""
+}
+
+func (d *lintDoc) line(key string) int {
+	if f, ok := d.Fields[key]; ok {
+		return f.Line
+	}
+	return 1
+}
+
+// gitCryptHeader prefixes files git-crypt hasn't decrypted.
+var gitCryptHeader = []byte("\x00GITCRYPT\x00")
+
+func isEncrypted(src []byte) bool {
+	return bytes.HasPrefix(src, gitCryptHeader)
+}
+
+func readLintDoc(path, kind string, md goldmark.Markdown) (*lintDoc, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isEncrypted(src) {
+		return nil, nil
+	}
+
+	ctx := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+	return newLintDoc(path, kind, src, doc, ctx)
+}
+
+// newLintDoc wraps a content file that's already been parsed.
+func newLintDoc(path, kind string, src []byte, doc ast.Node, ctx parser.Context) (*lintDoc, error) {
+	d := &lintDoc{
+		Path:   path,
+		Kind:   kind,
+		Slug:   strings.TrimSuffix(filepath.Base(path), ".md"),
+		Src:    src,
+		Doc:    doc,
+		Fields: make(map[string]lintField),
+	}
+	switch kind {
+	case "post":
+		d.Slug = postSlug(filepath.Base(path))
+	case "project":
+		d.Slug = projectSlug(path)
+	case "project-page":
+		d.Slug = filepath.Base(filepath.Dir(path)) + "/" + d.Slug
+	}
+
+	fm := frontmatter.Get(ctx)
+	if fm == nil {
+		return d, nil
+	}
+	var root yaml.Node
+	if err := fm.Decode(&root); err != nil {
+		return nil, fmt.Errorf("frontmatter: %w", err)
+	}
+	d.HasMeta = true
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return d, nil
+	}
+	m := root.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		// Frontmatter starts after the opening "---" on line 1
+		d.Fields[m.Content[i].Value] = lintField{Value: m.Content[i+1], Line: m.Content[i].Line + 1}
+	}
+	return d, nil
+}
+
+// contentFile is a markdown file in the content tree and the kind of
+// document the loaders treat it as.
+type contentFile struct {
+	Path    string
+	Kind    string
+	Slug    string // set for pages, whose slug is their path under pages/
+	Private bool
+}
+
+// contentFiles lists every markdown file the server loads from dir, in the
+// same layout the loaders expect.
+func contentFiles(dir string) ([]contentFile, error) {
+	sources := []struct {
+		pattern string
+		kind    string
+		private bool
+	}{
+		{"posts/*.md", "post", false},
+		{"private/*.md", "post", true},
+		{"projects/*.md", "project", false},
+		{"projects/*/*.md", "project-page", false},
+		{"tags/*.md", "tag", false},
+	}
+
+	var files []contentFile
+	for _, s := range sources {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(s.pattern)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range matches {
+			kind := s.kind
+			if kind == "project-page" && filepath.Base(f) == "index.md" {
+				kind = "project"
+			}
+			files = append(files, contentFile{Path: f, Kind: kind, Private: s.private})
+		}
+	}
+
+	pages, err := pageFiles(filepath.Join(dir, "pages"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range pages {
+		files = append(files, contentFile{Path: f, Kind: "page", Slug: pageSlug(filepath.Join(dir, "pages"), f)})
+	}
+	return files, nil
+}
+
+// linter holds everything in the content tree that links can point at.
+type linter struct {
+	dir       string
+	md        goldmark.Markdown
+	docs      []*lintDoc
+	posts     map[string]*lintDoc
+	projects  map[string]bool
+	subpages  map[string]bool // "project/page", including "project/changelog"
+	pages     map[string]bool
+	tags      map[string]bool
+	series    map[string]bool
+	moved     map[string]bool // old paths served as redirects
+	retracted map[string]bool
+	issues    []lintIssue
+}
+
+func (l *linter) report(file string, line int, format string, args ...any) {
+	l.issues = append(l.issues, lintIssue{File: file, Line: line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// lintContent loads every content file under dir and reports problems that
+// the server would otherwise skip or render silently. Files still encrypted
+// by git-crypt are ignored.
+func lintContent(dir string, md goldmark.Markdown) ([]lintIssue, error) {
+	l := &linter{
+		dir:       dir,
+		md:        md,
+		posts:     make(map[string]*lintDoc),
+		projects:  make(map[string]bool),
+		subpages:  make(map[string]bool),
+		pages:     make(map[string]bool),
+		tags:      make(map[string]bool),
+		series:    make(map[string]bool),
+		moved:     make(map[string]bool),
+		retracted: make(map[string]bool),
+	}
+
+	files, err := contentFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		d, err := readLintDoc(f.Path, f.Kind, md)
+		if err != nil {
+			l.report(f.Path, 1, "%v", err)
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		d.Private = f.Private
+		if f.Slug != "" {
+			d.Slug = f.Slug
+		}
+		l.docs = append(l.docs, d)
+	}
+
+	projectFiles := make(map[string]string) // slug → file
+	for _, d := range l.docs {
+		switch d.Kind {
+		case "post":
+			if prev, ok := l.posts[d.Slug]; ok {
+				l.report(d.Path, 1, "duplicate slug %q (also used by %s)", d.Slug, prev.Path)
+				continue
+			}
+			l.posts[d.Slug] = d
+			if f, ok := d.Fields["tags"]; ok {
+				for _, t := range f.Value.Content {
+					l.tags[normalizeTag(t.Value)] = true
+				}
+			}
+			if s := d.str("series"); s != "" {
+				l.series[slugify(s)] = true
+			}
+		case "project":
+			if l.projects[d.Slug] {
+				l.report(d.Path, 1, "duplicate slug %q (also used by %s)", d.Slug, projectFiles[d.Slug])
+				continue
+			}
+			l.projects[d.Slug] = true
+			projectFiles[d.Slug] = d.Path
+			if filepath.Base(d.Path) == "index.md" {
+				l.lintChangelog(d)
+			}
+		case "project-page":
+			if filepath.Base(d.Path) == "changelog.md" {
+				entries, _ := loadChangelog(filepath.Join(filepath.Dir(d.Path), "changelog.yaml"), l.md)
+				if len(entries) > 0 {
+					l.report(d.Path, 1, "page /projects/%s is shadowed by changelog.yaml", d.Slug)
+				}
+			}
+			l.subpages[d.Slug] = true
+		case "page":
+			if l.shadowed("/" + d.Slug) {
+				l.report(d.Path, 1, "page /%s is shadowed by a built-in route", d.Slug)
+			}
+			l.pages[d.Slug] = true
+		}
+	}
+
+	l.lintRedirects()
+	l.lintRetractions()
+
+	for _, d := range l.docs {
+		l.lintFrontmatter(d)
+		l.lintLinks(d)
+		l.issues = append(l.issues, renderIssues(d)...)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		if l.issues[i].File != l.issues[j].File {
+			return l.issues[i].File < l.issues[j].File
+		}
+		return l.issues[i].Line < l.issues[j].Line
+	})
+	return l.issues, nil
+}
+
+// lintChangelog checks the changelog.yaml next to a directory project's
+// index.md, if there is one.
+func (l *linter) lintChangelog(d *lintDoc) {
+	path := filepath.Join(filepath.Dir(d.Path), "changelog.yaml")
+	entries, err := loadChangelog(path, l.md)
+	if err != nil {
+		l.report(path, 0, "%v", err)
+		return
+	}
+	if len(entries) > 0 {
+		l.subpages[d.Slug+"/changelog"] = true
+	}
+}
+
+// aliasSections is the path an alias slug is relative to, per doc kind.
+var aliasSections = map[string]string{"post": "/posts/", "project": "/projects/", "page": "/"}
+
+// lintRedirects checks frontmatter aliases and content/redirects. A redirect
+// from a live path is never served, since redirects only replace 404s.
+func (l *linter) lintRedirects() {
+	for _, d := range l.docs {
+		f, ok := d.Fields["aliases"]
+		if !ok || f.Value.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, a := range f.Value.Content {
+			path := aliasPath(aliasSections[d.Kind], a.Value)
+			if l.checkLink(d, path) == "" {
+				l.report(d.Path, a.Line+1, "alias %s is a live page and will never redirect", path)
+			}
+		}
+	}
+
+	path := filepath.Join(l.dir, "redirects")
+	rules, err := loadRedirects(l.dir)
+	if err != nil {
+		l.report(path, 0, "%v", err)
+	}
+	from := &lintDoc{Path: path}
+	for _, r := range rules {
+		if l.checkLink(from, r.From) == "" {
+			l.report(path, r.Line, "redirect from %s is a live page and will never apply", r.From)
+		}
+	}
+
+	for _, d := range l.docs {
+		if f, ok := d.Fields["aliases"]; ok {
+			for _, a := range f.Value.Content {
+				l.moved[aliasPath(aliasSections[d.Kind], a.Value)] = true
+			}
+		}
+	}
+	for _, r := range rules {
+		l.moved[r.From] = true
+	}
+	for _, r := range rules {
+		if msg := l.checkLink(from, r.To); msg != "" {
+			l.report(path, r.Line, "%s", msg)
+		}
+	}
+}
+
+func (l *linter) lintRetractions() {
+	path := filepath.Join(l.dir, "retracted.yaml")
+	list, err := loadRetractions(l.dir)
+	if err != nil {
+		l.report(path, 0, "%v", err)
+		return
+	}
+	for _, r := range list {
+		l.retracted[r.Slug] = true
+		if p, ok := l.posts[r.Slug]; ok {
+			l.report(p.Path, 1, "post %q is listed as retracted in %s", r.Slug, path)
+		}
+	}
+}
+
+func (l *linter) lintFrontmatter(d *lintDoc) {
+	if !d.HasMeta {
+		if d.Kind != "tag" {
+			l.report(d.Path, 1, "missing frontmatter")
+		}
+		return
+	}
+
+	if s, ok := schemas[d.Kind]; ok {
+		l.issues = append(l.issues, s.check(d)...)
+	}
+
+	if img := d.str("image"); img != "" {
+		if msg := l.checkLink(d, img); msg != "" {
+			l.report(d.Path, d.line("image"), "%s", msg)
+		}
+	}
+
+	if d.Kind == "post" {
+		if p := d.str("project"); p != "" && !l.projects[p] {
+			l.report(d.Path, d.line("project"), "unknown project %q", p)
+		}
+	}
+}
+
+func (l *linter) lintLinks(d *lintDoc) {
+	ast.Walk(d.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Link:
+			if msg := l.checkLink(d, string(n.Destination)); msg != "" {
+				l.report(d.Path, nodeLine(n, d.Src), "%s", msg)
+			}
+		case *ast.Image:
+			if msg := l.checkLink(d, string(n.Destination)); msg != "" {
+				l.report(d.Path, nodeLine(n, d.Src), "%s", msg)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// renderIssues reports the math, diagrams and code blocks in d that
+// failed to render.
+func renderIssues(d *lintDoc) []lintIssue {
+	issues := append(mathIssues(d), diagramIssues(d)...)
+	return append(issues, codeIssues(d)...)
+}
+
+// contentIssues are the problems the loaders find in a content file, so
+// reload reports what blog lint would without parsing everything again.
+type contentIssues struct {
+	Frontmatter []lintIssue // fail the reload in strict mode
+	Render      []lintIssue // the page still shows the source
+}
+
+func (c *contentIssues) add(o contentIssues) {
+	c.Frontmatter = append(c.Frontmatter, o.Frontmatter...)
+	c.Render = append(c.Render, o.Render...)
+}
+
+// checkDoc checks the frontmatter and rendering of a file the loaders have
+// parsed. Frontmatter that doesn't decode is reported like blog lint does,
+// on line 1.
+func checkDoc(path, kind string, src []byte, doc ast.Node, ctx parser.Context) contentIssues {
+	if isEncrypted(src) {
+		return contentIssues{}
+	}
+	d, err := newLintDoc(path, kind, src, doc, ctx)
+	if err != nil {
+		return contentIssues{Frontmatter: []lintIssue{{File: path, Line: 1, Msg: err.Error()}}}
+	}
+	var c contentIssues
+	if s, ok := schemas[kind]; ok {
+		c.Frontmatter = s.check(d)
+	}
+	c.Render = append(renderIssues(d), mathWarnings(d)...)
+	return c
+}
+
+// siteRoutes are the fixed top-level paths registered in Serve.
+var siteRoutes = map[string]bool{
+	"/": true, "/posts": true, "/projects": true, "/tags": true, "/archive": true,
+	"/search": true, "/rss.xml": true, "/subscribe": true,
+	"/now/archive": true, "/now/feed.xml": true, "/sitemap.xml": true, "/robots.txt": true,
+}
+
+// checkLink returns a problem description if dest is an internal link that
+// wouldn't resolve. External links and in-page anchors aren't checked.
+func (l *linter) checkLink(from *lintDoc, dest string) string {
+	if !strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "//") {
+		return ""
+	}
+	u, err := url.Parse(dest)
+	if err != nil {
+		return fmt.Sprintf("malformed link %q", dest)
+	}
+	path := u.Path
+
+	if siteRoutes[path] || l.moved[path] {
+		return ""
+	}
+	if _, _, ok := parseArchivePath(path); ok {
+		return ""
+	}
+
+	section, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	switch section {
+	case "images":
+		if !fileExists(filepath.Join(l.dir, "images", filepath.FromSlash(rest))) {
+			return fmt.Sprintf("image %s not found in %s", dest, filepath.Join(l.dir, "images"))
+		}
+		return ""
+	case "static":
+		if !fileExists(filepath.Join("static", filepath.FromSlash(rest))) {
+			return fmt.Sprintf("broken link %s: no such static file", dest)
+		}
+		return ""
+	case "posts":
+		p, ok := l.posts[rest]
+		if !ok && l.retracted[rest] {
+			return fmt.Sprintf("broken link %s: post %q was retracted", dest, rest)
+		}
+		if !ok {
+			return fmt.Sprintf("broken link %s: no post %q", dest, rest)
+		}
+		if p.Private && !from.Private {
+			return fmt.Sprintf("broken link %s: post %q is private", dest, rest)
+		}
+		return ""
+	case "projects":
+		slug, page, _ := strings.Cut(rest, "/")
+		if !l.projects[slug] {
+			return fmt.Sprintf("broken link %s: no project %q", dest, slug)
+		}
+		if page != "" && page != "changelog.xml" && !l.subpages[rest] {
+			return fmt.Sprintf("broken link %s: project %q has no page %q", dest, slug, page)
+		}
+		if page == "changelog.xml" && !l.subpages[slug+"/changelog"] {
+			return fmt.Sprintf("broken link %s: project %q has no changelog", dest, slug)
+		}
+		return ""
+	case "tags":
+		if !l.tags[rest] {
+			return fmt.Sprintf("broken link %s: no posts tagged %q", dest, rest)
+		}
+		return ""
+	case "series":
+		if !l.series[rest] {
+			return fmt.Sprintf("broken link %s: no series %q", dest, rest)
+		}
+		return ""
+	}
+
+	if l.pages[strings.TrimPrefix(path, "/")] {
+		return ""
+	}
+	return fmt.Sprintf("broken link %s", dest)
+}
+
+// shadowed reports whether path is served by a route registered ahead of
+// content pages.
+func (l *linter) shadowed(path string) bool {
+	if siteRoutes[path] {
+		return true
+	}
+	if _, _, ok := parseArchivePath(path); ok {
+		return true
+	}
+	section, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if section == "now" && rest != "" {
+		_, ok := nowSnapshotSlug(strings.TrimPrefix(path, "/"))
+		return !ok
+	}
+	switch section {
+	case "images", "img", "static", "posts", "projects", "tags", "series", "api", "subscribe", "deploy", "sitemap", "og":
+		return true
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// nodeLine returns the 1-indexed source line of an inline node, using its
+// first text segment or, failing that, the start of its enclosing block.
+func nodeLine(n ast.Node, src []byte) int {
+	offset := -1
+	ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if t, ok := c.(*ast.Text); ok && entering {
+			offset = t.Segment.Start
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	for p := n; offset < 0 && p != nil; p = p.Parent() {
+		if p.Type() == ast.TypeBlock && p.Lines().Len() > 0 {
+			offset = p.Lines().At(0).Start
+		}
+	}
+	if offset < 0 {
+		return 0
+	}
+	return offsetLine(src, offset)
+}
+
+// offsetLine returns the 1-indexed line of a byte offset in src.
+func offsetLine(src []byte, offset int) int {
+	return bytes.Count(src[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,246 @@
+package blog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// writeContent creates files under a temp content dir from a path → body map.
+func writeContent(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLintContentClean(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-hello.md": `---
+title: Hello
+date: 2026-01-01
+tags: [go]
+project: blog
+---
+See [the project](/projects/blog), [go posts](/tags/go), [uses](/uses),
+[2026](/2026/) and [a heading](/posts/hello#intro).
+
+![shot](/images/shot.png)
+`,
+		"projects/blog.md": "---\ntitle: Blog\n---\n",
+		"pages/uses.md":    "---\ntitle: Uses\n---\n",
+		"images/shot.png":  "png",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestLintContentProblems(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-03-15-typo.md": `---
+title: Typo
+date: 2026-3-15
+project: nope
+---
+Intro spanning a line,
+then a [dead link](/posts/missing) and a [private one](/posts/secret).
+
+![gone](/images/gone.png)
+`,
+		"posts/no-meta.md":               "Just text.\n",
+		"posts/2026-01-01-untitled.md":   "---\ndate: 2026-01-01\ntags: go\n---\n",
+		"private/2026-01-02-secret.md":   "---\ntitle: Secret\ndate: 2026-01-02\n---\n",
+		"private/2026-01-03-untitled.md": "---\ntitle: Dupe\ndate: 2026-01-03\n---\n",
+		"private/2026-01-04-locked.md":   "\x00GITCRYPT\x00binary",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`posts/2026-01-01-untitled.md:1: missing required field "title"`,
+		`posts/2026-01-01-untitled.md:3: "tags" must be a list, e.g. [go, web]`,
+		`posts/2026-03-15-typo.md:3: invalid date "2026-3-15" for "date", want YYYY-MM-DD`,
+		`posts/2026-03-15-typo.md:4: unknown project "nope"`,
+		`posts/2026-03-15-typo.md:7: broken link /posts/missing: no post "missing"`,
+		`posts/2026-03-15-typo.md:7: broken link /posts/secret: post "secret" is private`,
+		`posts/2026-03-15-typo.md:9: image /images/gone.png not found in ` + filepath.Join(dir, "images"),
+		`posts/no-meta.md:1: missing frontmatter`,
+		`private/2026-01-03-untitled.md:1: duplicate slug "untitled" (also used by ` + filepath.Join(dir, "posts", "2026-01-01-untitled.md") + `)`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d issues, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("issue %d:\n got  %s\n want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLintContentProjectDirectory(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"projects/tori/index.md":       "---\ntitle: Tori\n---\nSee [install](/projects/tori/install), [releases](/projects/tori/changelog) and [docs](/projects/tori/docs).\n",
+		"projects/tori/install.md":     "---\ntitle: Install\nstatus: active\n---\n",
+		"projects/tori/changelog.yaml": "- version: v1\n  date: soon\n",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`projects/tori/changelog.yaml: v1: invalid date "soon", want YYYY-MM-DD`,
+		`projects/tori/index.md:4: broken link /projects/tori/changelog: project "tori" has no page "changelog"`,
+		`projects/tori/index.md:4: broken link /projects/tori/docs: project "tori" has no page "docs"`,
+		`projects/tori/install.md:3: unknown field "status"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintContentProjectConflicts(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"projects/tori.md":             "---\ntitle: Tori\n---\n",
+		"projects/tori/index.md":       "---\ntitle: Tori again\n---\n",
+		"projects/kiln/index.md":       "---\ntitle: Kiln\n---\n",
+		"projects/kiln/changelog.md":   "---\ntitle: Changelog\n---\n",
+		"projects/kiln/changelog.yaml": "- version: v0.1.0\n  date: 2026-01-01\n",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`projects/kiln/changelog.md:1: page /projects/kiln/changelog is shadowed by changelog.yaml`,
+		`projects/tori/index.md:1: duplicate slug "tori" (also used by ` + filepath.Join(dir, "projects", "tori.md") + `)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintContentPages(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"pages/talks/2026.md": "---\ntitle: Talks\nnav: yes\n---\nSee [uses](/uses) and [the archive](/talks/2025).\n",
+		"pages/uses.md":       "---\ntitle: Uses\n---\n",
+		"pages/tags.md":       "---\ntitle: Tags\n---\n",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`pages/tags.md:1: page /tags is shadowed by a built-in route`,
+		`pages/talks/2026.md:3: "nav" must be true or false`,
+		`pages/talks/2026.md:5: broken link /talks/2025`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintContentRedirects(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-new.md": "---\ntitle: New\ndate: 2026-01-01\naliases: [old, new]\n---\nStill links to [the old slug](/posts/old).\n",
+		"pages/about.md":          "---\ntitle: About\n---\n",
+		"redirects":               "# moved pages\n/me /about\n/about /posts/new\n/gone /posts/missing\n",
+	})
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("lintContent: %v", err)
+	}
+
+	var got []string
+	for _, i := range issues {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`posts/2026-01-01-new.md:4: alias /posts/new is a live page and will never redirect`,
+		`redirects:3: redirect from /about is a live page and will never apply`,
+		`redirects:4: broken link /posts/missing: no post "missing"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckDoc(t *testing.T) {
+	md := newMarkdown()
+	check := func(src string) contentIssues {
+		ctx := parser.NewContext()
+		doc := md.Parser().Parse(text.NewReader([]byte(src)), parser.WithContext(ctx))
+		return checkDoc("post.md", "post", []byte(src), doc, ctx)
+	}
+
+	c := check("---\ntitle: Hi\ndate: 2026-01-01\ndescripton: typo\n---\nBroken $\\frac{a$.\n")
+	if len(c.Frontmatter) != 1 || c.Frontmatter[0].Line != 4 || !strings.Contains(c.Frontmatter[0].Msg, `unknown field "descripton"`) {
+		t.Errorf("frontmatter issues = %v, want the unknown field", c.Frontmatter)
+	}
+	if len(c.Render) != 1 || c.Render[0].Line != 6 {
+		t.Errorf("render issues = %v, want the math error", c.Render)
+	}
+
+	c = check("---\ntitle: [Hi\n---\n")
+	if len(c.Frontmatter) != 1 || c.Frontmatter[0].Line != 1 || !strings.HasPrefix(c.Frontmatter[0].Msg, "frontmatter: yaml:") {
+		t.Errorf("frontmatter issues = %v, want the YAML error", c.Frontmatter)
+	}
+
+	if c := check("\x00GITCRYPT\x00$x$"); len(c.Frontmatter)+len(c.Render) != 0 {
+		t.Errorf("encrypted files should be skipped, got %v", c)
+	}
+}
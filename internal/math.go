@@ -0,0 +1,408 @@
+package blog
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	_ "unsafe" // for go:linkname
+
+	"github.com/wyatt915/treeblood"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Math is written as LaTeX between dollar signs: $x^2$ inline, and $$ on
+// lines of its own around a display equation. It's converted to MathML
+// while parsing, so pages, feeds and the static export need no script.
+// The opening $ can't be followed by a space, or the closing one preceded
+// by one or followed by a digit, so prices like $5 and $10 stay text.
+//
+// TeX that doesn't convert is shown as source with the math-error class,
+// and reported by reload and blog lint, along with treeblood's warnings.
+
+var (
+	kindMath      = ast.NewNodeKind("Math")
+	kindMathBlock = ast.NewNodeKind("MathBlock")
+)
+
+// mathResult is the TeX of a math node and what it converted to.
+type mathResult struct {
+	TeX    string
+	Offset int // in the source, for reporting errors
+	MathML string
+	Err    string
+	Warn   []string // from treeblood, for equations it converted anyway
+}
+
+type mathNode struct {
+	ast.BaseInline
+	mathResult
+	Display bool
+}
+
+func (n *mathNode) Kind() ast.NodeKind { return kindMath }
+
+func (n *mathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX}, nil)
+}
+
+type mathBlockNode struct {
+	ast.BaseBlock
+	mathResult
+	closed bool
+}
+
+func (n *mathBlockNode) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlockNode) IsRaw() bool { return true }
+
+func (n *mathBlockNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX}, nil)
+}
+
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(mathBlockParser{}, 700)),
+		parser.WithInlineParsers(util.Prioritized(mathParser{}, 500)),
+		parser.WithASTTransformers(util.Prioritized(mathTransformer{}, 500)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(mathRenderer{}, 500),
+	))
+}
+
+// mathBlockParser parses $$ display math $$, with the dollars either on
+// lines of their own or around a single line.
+type mathBlockParser struct{}
+
+func (mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	node := &mathBlockNode{}
+	rest := trimmed[2:]
+	if tex, ok := bytes.CutSuffix(rest, []byte("$$")); ok && len(rest) >= 2 {
+		start := segment.Start + bytes.Index(line, trimmed) + 2
+		node.Lines().Append(text.NewSegment(start, start+len(tex)))
+		node.closed = true
+	} else if len(rest) > 0 {
+		return nil, parser.NoChildren
+	}
+	reader.Advance(segment.Len() - trailingNewline(line))
+	return node, parser.NoChildren
+}
+
+func (mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*mathBlockNode)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return parser.Close
+	}
+	if string(bytes.TrimSpace(line)) == "$$" {
+		reader.Advance(segment.Len() - trailingNewline(line))
+		n.closed = true
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	reader.AdvanceLine()
+	return parser.Continue | parser.NoChildren
+}
+
+func (mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+	n := node.(*mathBlockNode)
+	var tex strings.Builder
+	for i := 0; i < n.Lines().Len(); i++ {
+		seg := n.Lines().At(i)
+		tex.Write(seg.Value(reader.Source()))
+	}
+	n.TeX = strings.TrimSpace(tex.String())
+	if n.Lines().Len() > 0 {
+		n.Offset = n.Lines().At(0).Start
+	}
+}
+
+func (mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+// mathParser parses $inline$ math, and $$display$$ math within a line.
+type mathParser struct{}
+
+func (mathParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	delim := 1
+	if len(line) > 1 && line[1] == '$' {
+		delim = 2
+	}
+	if len(line) <= delim || isSpace(line[delim]) {
+		return nil
+	}
+	end := closingDollars(line, delim)
+	if end < 0 {
+		return nil
+	}
+	n := &mathNode{}
+	n.TeX = string(line[delim:end])
+	n.Offset = segment.Start + delim
+	block.Advance(end + delim)
+	n.Display = delim == 2
+	return n
+}
+
+// closingDollars returns the index of the delim dollars closing the math
+// that starts at line[delim], or -1 if the line has none.
+func closingDollars(line []byte, delim int) int {
+	for i := delim + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '$':
+			if i+delim > len(line) || !bytes.Equal(line[i:i+delim], []byte("$$")[:delim]) {
+				continue
+			}
+			if isSpace(line[i-1]) {
+				continue
+			}
+			if delim == 1 && i+1 < len(line) && (line[i+1] == '$' || isDigit(line[i+1])) {
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// mathTransformer converts every math node in a document to MathML.
+type mathTransformer struct{}
+
+func (mathTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if isEncrypted(reader.Source()) {
+		// ciphertext isn't TeX, whatever dollar signs it happens to have
+		return
+	}
+	var pitz *treeblood.Pitziil
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var m *mathResult
+		display := false
+		switch n := n.(type) {
+		case *mathNode:
+			m = &n.mathResult
+			display = n.Display
+		case *mathBlockNode:
+			m = &n.mathResult
+			display = true
+		default:
+			return ast.WalkContinue, nil
+		}
+		if pitz == nil {
+			// one per document, so \newcommand carries over between equations
+			pitz = treeblood.NewPitziil()
+			pitz.PrintOneLine = true
+		}
+		m.MathML, m.Warn, m.Err = convertMath(pitz, m.TeX, display)
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// convertMath returns tex as MathML with any warnings, or the reason it
+// can't be converted.
+func convertMath(pitz *treeblood.Pitziil, tex string, display bool) (mathml string, warn []string, errMsg string) {
+	if strings.TrimSpace(tex) == "" {
+		return "", nil, "empty equation"
+	}
+	convert := pitz.TextStyle
+	if display {
+		convert = pitz.DisplayStyle
+	}
+
+	mathLog.Lock()
+	mathLog.warn = nil
+	out, err := convert(tex)
+	warn = mathLog.warn
+	mathLog.Unlock()
+
+	if err != nil {
+		// the message ends in an HTML excerpt pointing at the problem
+		msg, _, _ := strings.Cut(err.Error(), "<")
+		return "", nil, strings.TrimSpace(msg)
+	}
+	if m := mathError.FindStringSubmatch(out); m != nil {
+		// treeblood marks what it doesn't understand and carries on
+		name := html.UnescapeString(mathTags.ReplaceAllString(m[2], ""))
+		if t := mathTitle.FindStringSubmatch(m[1]); t != nil {
+			return "", nil, name + ": " + strings.TrimSpace(html.UnescapeString(t[1]))
+		}
+		return "", nil, `unknown command \` + strings.TrimPrefix(name, `\`)
+	}
+	return sortMathAttrs(strings.TrimSpace(out)), warn, ""
+}
+
+// treebloodLogger is the logger treeblood writes its warnings to. It isn't
+// exported, and goes to stderr by default; init points it at mathLog so
+// the warnings end up with the equation that caused them.
+//
+//go:linkname treebloodLogger github.com/wyatt915/treeblood.logger
+var treebloodLogger *log.Logger
+
+// mathLog collects treeblood's warnings for the equation being converted.
+// Conversions hold the lock, since the logger is shared.
+var mathLog struct {
+	sync.Mutex
+	warn []string
+}
+
+func init() {
+	treebloodLogger.SetOutput(mathLogWriter{})
+	treebloodLogger.SetFlags(0)
+	treebloodLogger.SetPrefix("")
+}
+
+type mathLogWriter struct{}
+
+// Write records one warning. The excerpts treeblood logs after some of
+// them, which start on a new line, are left out.
+func (mathLogWriter) Write(p []byte) (int, error) {
+	if msg := string(p); !strings.HasPrefix(msg, "\n") {
+		msg, _, _ = strings.Cut(strings.TrimSpace(msg), "\n")
+		for _, level := range []string{"WARN: ", "NOTE: "} {
+			msg = strings.TrimPrefix(msg, level)
+		}
+		if msg != "" && !slices.Contains(mathLog.warn, msg) {
+			mathLog.warn = append(mathLog.warn, msg)
+		}
+	}
+	return len(p), nil
+}
+
+var (
+	mathError = regexp.MustCompile(`<merror([^>]*)>(.*?)</merror>`)
+	mathTitle = regexp.MustCompile(`title="([^"]*)"`)
+	mathTags  = regexp.MustCompile(`<[^>]*>`)
+)
+
+var (
+	mathTag  = regexp.MustCompile(`<(\w+)((?:\s+[\w:-]+="[^"]*")+)\s*(/?)>`)
+	mathAttr = regexp.MustCompile(`[\w:-]+="[^"]*"`)
+)
+
+// sortMathAttrs sorts the attributes of every tag, since treeblood writes
+// them in map order and pages should render the same on every reload.
+func sortMathAttrs(mathml string) string {
+	return mathTag.ReplaceAllStringFunc(mathml, func(tag string) string {
+		m := mathTag.FindStringSubmatch(tag)
+		attrs := mathAttr.FindAllString(m[2], -1)
+		sort.Strings(attrs)
+		return "<" + m[1] + " " + strings.Join(attrs, " ") + m[3] + ">"
+	})
+}
+
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, renderMath)
+	reg.Register(kindMathBlock, renderMathBlock)
+}
+
+func renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathNode)
+	if n.Err != "" {
+		w.WriteString(`<code class="math math-error" title="`)
+		w.Write(util.EscapeHTML([]byte(n.Err)))
+		w.WriteString(`">`)
+		w.Write(util.EscapeHTML([]byte(n.TeX)))
+		w.WriteString("</code>")
+		return ast.WalkSkipChildren, nil
+	}
+	w.WriteString(n.MathML)
+	return ast.WalkSkipChildren, nil
+}
+
+func renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathBlockNode)
+	if n.Err != "" {
+		w.WriteString(`<pre class="math math-error" title="`)
+		w.Write(util.EscapeHTML([]byte(n.Err)))
+		w.WriteString(`">`)
+		w.Write(util.EscapeHTML([]byte(n.TeX)))
+		w.WriteString("</pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+	w.WriteString(`<div class="math">` + n.MathML + "</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// mathIssues reports the equations in d that failed to convert.
+func mathIssues(d *lintDoc) []lintIssue {
+	var issues []lintIssue
+	ast.Walk(d.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		var m *mathResult
+		switch n := n.(type) {
+		case *mathNode:
+			m = &n.mathResult
+		case *mathBlockNode:
+			m = &n.mathResult
+		}
+		if entering && m != nil && m.Err != "" {
+			issues = append(issues, lintIssue{File: d.Path, Line: offsetLine(d.Src, m.Offset), Msg: fmt.Sprintf("math: %s", m.Err)})
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
+
+// mathWarnings reports treeblood's warnings for the equations in d. They
+// go in the reload log but don't fail blog lint: a half-open interval like
+// [0, 1) is an unmatched delimiter to treeblood.
+func mathWarnings(d *lintDoc) []lintIssue {
+	var issues []lintIssue
+	ast.Walk(d.Doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		var m *mathResult
+		switch n := n.(type) {
+		case *mathNode:
+			m = &n.mathResult
+		case *mathBlockNode:
+			m = &n.mathResult
+		}
+		if entering && m != nil {
+			for _, w := range m.Warn {
+				issues = append(issues, lintIssue{File: d.Path, Line: offsetLine(d.Src, m.Offset), Msg: fmt.Sprintf("math: %s", w)})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return issues
+}
@@ -0,0 +1,118 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		want      []string
+	}{
+		{
+			name: "inline",
+			src:  "Euler: $e^{i\\pi} + 1 = 0$.\n",
+			want: []string{
+				`<p>Euler: <math class="math-textstyle" display="inline"`,
+				`<msup><mi>e</mi>`,
+				`<annotation encoding="application/x-tex">e^{i\pi} + 1 = 0</annotation></semantics></math>.</p>`,
+			},
+		},
+		{
+			name: "display block",
+			src:  "Sum:\n$$\n\\sum_{i=0}^n i\n$$\n",
+			want: []string{"<p>Sum:</p>\n<div class=\"math\"><math class=\"math-displaystyle\" display=\"block\""},
+		},
+		{
+			name: "display on one line",
+			src:  "$$x^2$$\n",
+			want: []string{`<div class="math"><math class="math-displaystyle"`},
+		},
+		{
+			name: "display within a paragraph",
+			src:  "so $$x^2$$ holds\n",
+			want: []string{`<p>so <math class="math-displaystyle"`},
+		},
+		{
+			name: "prices stay text",
+			src:  "It costs $5, or $ 10 and $20 for two.\n",
+			want: []string{"<p>It costs $5, or $ 10 and $20 for two.</p>"},
+		},
+		{
+			name: "escaped and code",
+			src:  "`$x$` and \\$x$\n",
+			want: []string{"<p><code>$x$</code> and $x$</p>"},
+		},
+		{
+			name: "syntax error",
+			src:  "bad $\\frac{a <b$\n",
+			want: []string{`<code class="math math-error" title="mismatched curly brace at position 1">\frac{a &lt;b</code>`},
+		},
+		{
+			name: "unknown command",
+			src:  "$$\n\\foo{x}\n$$\n",
+			want: []string{"<pre class=\"math math-error\" title=\"unknown command \\foo\">\\foo{x}</pre>"},
+		},
+	} {
+		out := renderMarkdown(t, tt.src)
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: missing %s\ngot: %s", tt.name, want, out)
+			}
+		}
+	}
+}
+
+func TestMathIsStable(t *testing.T) {
+	src := "$\\begin{pmatrix}1&2\\\\3&4\\end{pmatrix}$\n"
+	first := renderMarkdown(t, src)
+	for range 20 {
+		if out := renderMarkdown(t, src); out != first {
+			t.Fatalf("output changed between renders:\n%s\n%s", first, out)
+		}
+	}
+}
+
+func TestMathIssues(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-math.md": "---\ntitle: Math\ndate: 2026-01-01\n---\nFine $x$.\n\nBroken $\\frac{a$.\n",
+	})
+
+	issues := postRenderIssues(t, dir, newMarkdown())
+	if len(issues) != 1 || issues[0].Line != 7 || !strings.Contains(issues[0].Msg, "math: mismatched curly brace") {
+		t.Errorf("issues = %v, want one for line 7", issues)
+	}
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Line != 7 {
+		t.Errorf("lint issues = %v, want the math error", issues)
+	}
+}
+
+func TestMathWarnings(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-math.md":     "---\ntitle: Math\ndate: 2026-01-01\n---\nIn $[0, 1)$.\n",
+		"private/2026-01-02-secret.md": "\x00GITCRYPT\x00$\\frac{a$ and $x)$",
+	})
+
+	issues := postRenderIssues(t, dir, newMarkdown())
+	if len(issues) != 1 || issues[0].Line != 5 || issues[0].Msg != "math: Potentially unmatched closing delimeter" {
+		t.Errorf("issues = %v, want the treeblood warning for line 5 only", issues)
+	}
+
+	issues, err := lintContent(dir, newMarkdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("warnings shouldn't fail blog lint: %v", issues)
+	}
+
+	if out := renderMarkdown(t, "\x00GITCRYPT\x00$x$"); strings.Contains(out, "<math") {
+		t.Errorf("encrypted files shouldn't be read as TeX: %s", out)
+	}
+}
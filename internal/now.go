@@ -0,0 +1,154 @@
+package blog
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+)
+
+// nowSnapshotSlug reports whether a page slug is an archived /now page,
+// stored as pages/now/YYYY-MM-DD.md, and returns its date.
+func nowSnapshotSlug(slug string) (time.Time, bool) {
+	date, ok := strings.CutPrefix(slug, "now/")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", date)
+	return t, err == nil
+}
+
+// loadNowHistory loads the archived /now pages, newest first. Each snapshot
+// is dated by its filename, the day it became the current /now page.
+func loadNowHistory(dir string, md goldmark.Markdown) ([]Page, contentIssues, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "pages", "now", "*.md"))
+	if err != nil {
+		return nil, contentIssues{}, err
+	}
+
+	var history []Page
+	var issues contentIssues
+	for _, f := range files {
+		date, ok := nowSnapshotSlug("now/" + strings.TrimSuffix(filepath.Base(f), ".md"))
+		if !ok {
+			continue
+		}
+		p, err := parsePage(f, "page", md)
+		if err != nil {
+			return nil, contentIssues{}, fmt.Errorf("parsing %s: %w", f, err)
+		}
+		issues.add(p.issues)
+		p.Slug = "now/" + date.Format("2006-01-02")
+		p.Updated = date
+		history = append(history, p)
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Updated.After(history[j].Updated)
+	})
+	return history, issues, nil
+}
+
+func (app *App) handleNow(w http.ResponseWriter, r *http.Request) {
+	app.mu.RLock()
+	page, ok := findPage(app.pages, "now")
+	history := app.nowHistory
+	app.mu.RUnlock()
+
+	if !ok {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	app.render(w, "now", map[string]any{
+		"Page":       page,
+		"Date":       page.Updated,
+		"HasHistory": len(history) > 0,
+		"BaseURL":    app.cfg.BaseURL,
+	})
+}
+
+func (app *App) handleNowSnapshot(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+
+	app.mu.RLock()
+	current, _ := findPage(app.pages, "now")
+	snapshot, ok := findPage(app.nowHistory, "now/"+date)
+	app.mu.RUnlock()
+
+	if !ok {
+		if !current.Updated.IsZero() && current.Updated.Format("2006-01-02") == date {
+			http.Redirect(w, r, "/now", http.StatusFound)
+			return
+		}
+		app.renderNotFound(w, r)
+		return
+	}
+
+	app.render(w, "now", map[string]any{
+		"Page":       snapshot,
+		"Date":       snapshot.Updated,
+		"Snapshot":   true,
+		"HasHistory": true,
+		"BaseURL":    app.cfg.BaseURL,
+	})
+}
+
+func (app *App) handleNowArchive(w http.ResponseWriter, r *http.Request) {
+	app.mu.RLock()
+	current, ok := findPage(app.pages, "now")
+	history := app.nowHistory
+	app.mu.RUnlock()
+
+	if !ok && len(history) == 0 {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	app.render(w, "now_archive", map[string]any{
+		"Current": current,
+		"History": history,
+	})
+}
+
+// handleNowFeed lists each version of the /now page as an item, so readers
+// get notified when it changes.
+func (app *App) handleNowFeed(w http.ResponseWriter, r *http.Request) {
+	app.mu.RLock()
+	current, ok := findPage(app.pages, "now")
+	history := app.nowHistory
+	app.mu.RUnlock()
+
+	versions := history
+	if ok && !current.Updated.IsZero() {
+		versions = append([]Page{current}, history...)
+	}
+
+	items := make([]rssItem, len(versions))
+	for i, p := range versions {
+		link := app.cfg.BaseURL + "/now/" + p.Updated.Format("2006-01-02")
+		items[i] = rssItem{
+			Title:       "Now: " + p.Updated.Format("January 2, 2006"),
+			Link:        link,
+			Description: string(p.Body),
+			PubDate:     p.Updated.Format(time.RFC1123Z),
+			GUID:        link,
+		}
+	}
+
+	lastBuild := time.Now()
+	if len(versions) > 0 {
+		lastBuild = versions[0].Updated
+	}
+	writeRSS(w, rssChannel{
+		Title:       "thobiasn.dev/now",
+		Link:        app.cfg.BaseURL + "/now",
+		Description: "What I'm doing now",
+		LastBuild:   lastBuild.Format(time.RFC1123Z),
+		Items:       items,
+	})
+}
@@ -0,0 +1,145 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func nowTestApp(t *testing.T) *App {
+	t.Helper()
+
+	app := testApp(t)
+	app.pages[1].Updated = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	app.nowHistory = []Page{
+		{Title: "Now", Slug: "now/2026-03-01", Updated: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Body: "<p>Writing a parser</p>"},
+		{Title: "Now", Slug: "now/2026-01-01", Updated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Body: "<p>Moving house</p>"},
+	}
+	return app
+}
+
+func TestLoadNowHistory(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"pages/now.md":            "---\ntitle: Now\nupdated: 2026-06-01\n---\nCurrent.\n",
+		"pages/now/2026-01-01.md": "---\ntitle: Now\n---\nOldest.\n",
+		"pages/now/2026-03-01.md": "---\ntitle: Now\n---\nOlder.\n",
+	})
+
+	history, _, err := loadNowHistory(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadNowHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].Slug != "now/2026-03-01" {
+		t.Fatalf("history = %+v, want newest first", history)
+	}
+
+	pages, _, err := loadAllPages(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadAllPages: %v", err)
+	}
+	if len(pages) != 1 || pages[0].Slug != "now" {
+		t.Errorf("pages = %+v, want only now (snapshots excluded)", pages)
+	}
+	if !pages[0].Updated.Equal(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Updated = %v, want 2026-06-01", pages[0].Updated)
+	}
+}
+
+func TestHandleNow(t *testing.T) {
+	app := nowTestApp(t)
+
+	req := httptest.NewRequest("GET", "/now", nil)
+	w := httptest.NewRecorder()
+	app.handleNow(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "doing now</p>") {
+		t.Error("/now should render the current page")
+	}
+	if !strings.Contains(body, `href="/now/archive"`) {
+		t.Error("/now should link to its archive")
+	}
+}
+
+func TestHandleNowSnapshot(t *testing.T) {
+	app := nowTestApp(t)
+
+	tests := []struct {
+		date string
+		code int
+		want string
+	}{
+		{"2026-03-01", http.StatusOK, "Writing a parser"},
+		{"2026-06-01", http.StatusFound, ""},
+		{"2026-02-01", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/now/"+tt.date, nil)
+		req.SetPathValue("date", tt.date)
+		w := httptest.NewRecorder()
+		app.handleNowSnapshot(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.date, w.Code, tt.code)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: body should contain %q", tt.date, tt.want)
+		}
+	}
+}
+
+func TestHandleNowArchive(t *testing.T) {
+	app := nowTestApp(t)
+
+	req := httptest.NewRequest("GET", "/now/archive", nil)
+	w := httptest.NewRecorder()
+	app.handleNowArchive(w, req)
+
+	body := w.Body.String()
+	if strings.Index(body, `href="/now/2026-03-01"`) > strings.Index(body, `href="/now/2026-01-01"`) {
+		t.Error("archive should list snapshots newest first")
+	}
+}
+
+func TestHandleNowFeed(t *testing.T) {
+	app := nowTestApp(t)
+
+	req := httptest.NewRequest("GET", "/now/feed.xml", nil)
+	w := httptest.NewRecorder()
+	app.handleNowFeed(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"Now: June 1, 2026", "Now: March 1, 2026", "Now: January 1, 2026"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("feed missing %q", want)
+		}
+	}
+}
+
+func TestArchiveNow(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"now.md": "---\ntitle: Now\nupdated: 2026-06-01\n---\nCurrent.\n",
+	})
+	path := filepath.Join(dir, "now.md")
+
+	if err := archiveNow(path, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("archiveNow: %v", err)
+	}
+
+	archived, err := os.ReadFile(filepath.Join(dir, "now", "2026-06-01.md"))
+	if err != nil || !strings.Contains(string(archived), "Current.") {
+		t.Errorf("archived copy = %q, %v", archived, err)
+	}
+	src, _ := os.ReadFile(path)
+	if !strings.Contains(string(src), "updated: 2026-10-19") {
+		t.Errorf("now.md should be dated today:\n%s", src)
+	}
+
+	if err := archiveNow(path, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("archiving twice on the same day should fail")
+	}
+}
@@ -0,0 +1,257 @@
+package blog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Social cards use the size recommended for summary_large_image.
+const (
+	ogWidth    = 1200
+	ogHeight   = 630
+	ogPadding  = 80
+	ogMaxLines = 3
+)
+
+var (
+	ogBackground = color.RGBA{0x1a, 0x1a, 0x1a, 0xff}
+	ogText       = color.RGBA{0xf5, 0xf5, 0xf5, 0xff}
+	ogMuted      = color.RGBA{0xa3, 0xa3, 0xa3, 0xff}
+	ogAccent     = color.RGBA{0x60, 0xa5, 0xfa, 0xff}
+)
+
+// ogCard is what goes on a social card. Its hash identifies the rendered
+// PNG, so unchanged cards aren't redrawn on reload.
+type ogCard struct {
+	Title    string
+	Subtitle string
+	Tags     []string
+}
+
+func (c ogCard) hash() string {
+	h := sha256.Sum256([]byte(c.Title + "\x00" + c.Subtitle + "\x00" + strings.Join(c.Tags, ",")))
+	return hex.EncodeToString(h[:8])
+}
+
+// ogImage is a rendered card and its ETag.
+type ogImage struct {
+	PNG  []byte
+	Hash string
+}
+
+type ogFonts struct {
+	title, meta, site font.Face
+}
+
+var (
+	ogFontsOnce sync.Once
+	ogFace      ogFonts
+	ogFontErr   error
+)
+
+func loadOGFonts() (ogFonts, error) {
+	ogFontsOnce.Do(func() {
+		bold, err := opentype.Parse(gobold.TTF)
+		if err != nil {
+			ogFontErr = err
+			return
+		}
+		regular, err := opentype.Parse(goregular.TTF)
+		if err != nil {
+			ogFontErr = err
+			return
+		}
+		face := func(f *opentype.Font, size float64) font.Face {
+			if ogFontErr != nil {
+				return nil
+			}
+			var ff font.Face
+			ff, ogFontErr = opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+			return ff
+		}
+		ogFace = ogFonts{
+			title: face(bold, 68),
+			meta:  face(regular, 32),
+			site:  face(bold, 32),
+		}
+	})
+	return ogFace, ogFontErr
+}
+
+// renderOGCard draws a card with the title wrapped over up to three lines,
+// then the subtitle and tags, and the site name at the bottom.
+func renderOGCard(c ogCard) ([]byte, error) {
+	fonts, err := loadOGFonts()
+	if err != nil {
+		return nil, fmt.Errorf("loading fonts: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, ogWidth, ogHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(ogBackground), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, ogWidth, 12), image.NewUniform(ogAccent), image.Point{}, draw.Src)
+
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(ogText), Face: fonts.title}
+	lineHeight := fonts.title.Metrics().Height.Ceil() + 8
+	y := ogPadding + fonts.title.Metrics().Ascent.Ceil()
+	for _, line := range wrapText(d, c.Title, ogWidth-2*ogPadding, ogMaxLines) {
+		d.Dot = fixed.P(ogPadding, y)
+		d.DrawString(line)
+		y += lineHeight
+	}
+
+	meta := c.Subtitle
+	if len(c.Tags) > 0 {
+		tags := "#" + strings.Join(c.Tags, "  #")
+		if meta != "" {
+			meta += "  ·  "
+		}
+		meta += tags
+	}
+	if meta != "" {
+		d.Face = fonts.meta
+		d.Src = image.NewUniform(ogMuted)
+		d.Dot = fixed.P(ogPadding, y+16)
+		d.DrawString(truncateText(d, meta, ogWidth-2*ogPadding))
+	}
+
+	d.Face = fonts.site
+	d.Src = image.NewUniform(ogAccent)
+	d.Dot = fixed.P(ogPadding, ogHeight-ogPadding)
+	d.DrawString("thobiasn.dev")
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// wrapText breaks s into lines no wider than width, ending the last line
+// with an ellipsis if it doesn't fit in maxLines.
+func wrapText(d *font.Drawer, s string, width, maxLines int) []string {
+	var lines []string
+	line := ""
+	words := strings.Fields(s)
+	for i, w := range words {
+		next := w
+		if line != "" {
+			next = line + " " + w
+		}
+		if d.MeasureString(next).Ceil() <= width || line == "" {
+			line = next
+			continue
+		}
+		lines = append(lines, line)
+		line = w
+		if len(lines) == maxLines-1 {
+			line = strings.Join(words[i:], " ")
+			break
+		}
+	}
+	if line != "" {
+		lines = append(lines, truncateText(d, line, width))
+	}
+	return lines
+}
+
+// truncateText shortens s with an ellipsis until it fits in width.
+func truncateText(d *font.Drawer, s string, width int) string {
+	if d.MeasureString(s).Ceil() <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && d.MeasureString(string(runes)+"…").Ceil() > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// buildOGImages renders a card for every post and project without an
+// image: override, reusing PNGs from prev whose content hasn't changed.
+// Posts are keyed by slug and projects by "projects/" + slug, matching
+// their URLs under /og/.
+func buildOGImages(posts []Post, projects []Project, prev map[string]ogImage) map[string]ogImage {
+	cached := make(map[string]ogImage, len(prev))
+	for _, img := range prev {
+		cached[img.Hash] = img
+	}
+
+	images := make(map[string]ogImage)
+	add := func(key string, c ogCard) {
+		h := c.hash()
+		if img, ok := cached[h]; ok {
+			images[key] = img
+			return
+		}
+		b, err := renderOGCard(c)
+		if err != nil {
+			log.Printf("og image %s: %v", key, err)
+			return
+		}
+		images[key] = ogImage{PNG: b, Hash: h}
+	}
+
+	for _, p := range posts {
+		if p.Image == "" {
+			add(p.Slug, ogCard{Title: p.Title, Subtitle: p.Date.Format("January 2, 2006"), Tags: p.Tags})
+		}
+	}
+	for _, p := range projects {
+		if p.Image == "" {
+			add("projects/"+p.Slug, ogCard{Title: p.Title, Subtitle: p.Description, Tags: p.Tags})
+		}
+	}
+	return images
+}
+
+// ogImageURL is the absolute URL of the card shown when key is shared:
+// the image: override if set, otherwise the generated card.
+func ogImageURL(base, override, key string) string {
+	if override != "" {
+		return absURL(base, override)
+	}
+	return base + "/og/" + key + ".png"
+}
+
+func (app *App) handleOGImage(w http.ResponseWriter, r *http.Request) {
+	app.serveOGImage(w, r, r.PathValue("file"))
+}
+
+func (app *App) handleProjectOGImage(w http.ResponseWriter, r *http.Request) {
+	app.serveOGImage(w, r, "projects/"+r.PathValue("file"))
+}
+
+func (app *App) serveOGImage(w http.ResponseWriter, r *http.Request, file string) {
+	key, ok := strings.CutSuffix(file, ".png")
+	app.mu.RLock()
+	img, found := app.ogImages[key]
+	app.mu.RUnlock()
+
+	if !ok || !found {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	// ServeContent answers If-None-Match with 304 using the ETag
+	w.Header().Set("ETag", `"`+img.Hash+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(img.PNG))
+}
@@ -0,0 +1,139 @@
+package blog
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+)
+
+func TestRenderOGCard(t *testing.T) {
+	b, err := renderOGCard(ogCard{
+		Title:    "A fairly long title about building a blog in Go that has to wrap over several lines to fit",
+		Subtitle: "February 25, 2026",
+		Tags:     []string{"go", "web"},
+	})
+	if err != nil {
+		t.Fatalf("renderOGCard: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("decoding card: %v", err)
+	}
+	if got := img.Bounds().Size(); got.X != ogWidth || got.Y != ogHeight {
+		t.Errorf("size = %v, want %dx%d", got, ogWidth, ogHeight)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	fonts, err := loadOGFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &font.Drawer{Face: fonts.title}
+	width := ogWidth - 2*ogPadding
+
+	lines := wrapText(d, strings.Repeat("word ", 100), width, ogMaxLines)
+	if len(lines) != ogMaxLines {
+		t.Fatalf("got %d lines, want %d", len(lines), ogMaxLines)
+	}
+	if !strings.HasSuffix(lines[len(lines)-1], "…") {
+		t.Errorf("last line %q should end with an ellipsis", lines[len(lines)-1])
+	}
+	for _, l := range lines {
+		if d.MeasureString(l).Ceil() > width {
+			t.Errorf("line %q overflows", l)
+		}
+	}
+
+	if lines := wrapText(d, "Short", width, ogMaxLines); len(lines) != 1 || lines[0] != "Short" {
+		t.Errorf("wrapText(Short) = %q", lines)
+	}
+}
+
+func TestBuildOGImages(t *testing.T) {
+	posts := []Post{
+		{Slug: "card", Title: "Card", Date: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "custom", Title: "Custom", Image: "/images/custom.png"},
+	}
+	projects := []Project{{Slug: "blog", Title: "Blog"}}
+
+	images := buildOGImages(posts, projects, nil)
+	if _, ok := images["card"]; !ok {
+		t.Error("post without image: should get a card")
+	}
+	if _, ok := images["custom"]; ok {
+		t.Error("post with image: override should not get a card")
+	}
+	if _, ok := images["projects/blog"]; !ok {
+		t.Error("project should get a card")
+	}
+
+	again := buildOGImages(posts, projects, images)
+	if &again["card"].PNG[0] != &images["card"].PNG[0] {
+		t.Error("unchanged card should be reused, not redrawn")
+	}
+}
+
+func TestHandleOGImage(t *testing.T) {
+	app := testApp(t)
+	app.ogImages = map[string]ogImage{"first-post": {PNG: []byte("\x89PNG fake"), Hash: "abc"}}
+
+	req := httptest.NewRequest("GET", "/og/first-post.png", nil)
+	req.SetPathValue("file", "first-post.png")
+	w := httptest.NewRecorder()
+	app.handleOGImage(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status = %d, Content-Type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("ETag") != `"abc"` || w.Header().Get("Cache-Control") == "" {
+		t.Errorf("missing caching headers: %v", w.Header())
+	}
+
+	req.Header.Set("If-None-Match", `"abc"`)
+	w = httptest.NewRecorder()
+	app.handleOGImage(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional request: status = %d, want 304", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/og/private-post.png", nil)
+	req.SetPathValue("file", "private-post.png")
+	w = httptest.NewRecorder()
+	app.handleOGImage(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown card: status = %d, want 404", w.Code)
+	}
+}
+
+func TestPostOGMeta(t *testing.T) {
+	app := testApp(t)
+
+	for _, tt := range []struct {
+		image string
+		want  string
+	}{
+		{"", `<meta property="og:image" content="http://localhost:8080/og/first-post.png">`},
+		{"/images/cover.png", `<meta property="og:image" content="http://localhost:8080/images/cover.png">`},
+	} {
+		app.posts[0].Image = tt.image
+		req := httptest.NewRequest("GET", "/posts/first-post", nil)
+		req.SetPathValue("slug", "first-post")
+		w := httptest.NewRecorder()
+		app.handlePost(w, req)
+
+		body := w.Body.String()
+		if !strings.Contains(body, tt.want) {
+			t.Errorf("image %q: missing %s", tt.image, tt.want)
+		}
+		if !strings.Contains(body, `<meta name="twitter:card" content="summary_large_image">`) {
+			t.Errorf("image %q: want summary_large_image card", tt.image)
+		}
+	}
+}
@@ -2,9 +2,46 @@ package blog
 
 import (
 	"net/http"
+	"sort"
 	"strings"
 )
 
+// navItem is a link in the site header.
+type navItem struct {
+	Title string
+	URL   string
+}
+
+// buildNav lists the header links: posts and projects, then pages with
+// nav: true ordered by nav_order and title, then search.
+func buildNav(pages []Page) []navItem {
+	var inNav []Page
+	for _, p := range pages {
+		if p.Nav {
+			inNav = append(inNav, p)
+		}
+	}
+	sort.SliceStable(inNav, func(i, j int) bool {
+		if inNav[i].NavOrder != inNav[j].NavOrder {
+			return inNav[i].NavOrder < inNav[j].NavOrder
+		}
+		return inNav[i].Title < inNav[j].Title
+	})
+
+	nav := []navItem{
+		{Title: "Posts", URL: "/posts"},
+		{Title: "Projects", URL: "/projects"},
+	}
+	for _, p := range inNav {
+		title := p.NavTitle
+		if title == "" {
+			title = p.Title
+		}
+		nav = append(nav, navItem{Title: title, URL: "/" + p.Slug})
+	}
+	return append(nav, navItem{Title: "Search", URL: "/search"})
+}
+
 func (app *App) handlePage(w http.ResponseWriter, r *http.Request) {
 	slug := strings.TrimPrefix(r.URL.Path, "/")
 
@@ -18,7 +55,8 @@ func (app *App) handlePage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	app.render(w, "page", map[string]any{
-		"Page": page,
+		"Page":   page,
+		"JSONLD": []jsonLD{breadcrumbLD(app.cfg.BaseURL, crumb{"Home", "/"}, crumb{page.Title, "/" + page.Slug})},
 	})
 }
 
@@ -55,3 +55,73 @@ func TestHandlePageNotFound(t *testing.T) {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
+
+func TestLoadAllPagesNested(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"pages/about.md":       "---\ntitle: About\nnav: true\n---\n",
+		"pages/talks/index.md": "---\ntitle: Talks\n---\n",
+		"pages/talks/2026.md":  "---\ntitle: Talks in 2026\n---\n",
+	})
+
+	pages, _, err := loadAllPages(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadAllPages: %v", err)
+	}
+
+	var slugs []string
+	for _, p := range pages {
+		slugs = append(slugs, p.Slug)
+	}
+	if got, want := strings.Join(slugs, " "), "about talks/2026 talks"; got != want {
+		t.Errorf("slugs = %q, want %q", got, want)
+	}
+	if !pages[0].Nav {
+		t.Error("about should be in the nav")
+	}
+}
+
+func TestHandleFallbackNestedPage(t *testing.T) {
+	app := testApp(t)
+	app.pages = append(app.pages, Page{Title: "Talks in 2026", Slug: "talks/2026", Body: "<p>Conference talks</p>"})
+
+	req := httptest.NewRequest("GET", "/talks/2026", nil)
+	w := httptest.NewRecorder()
+	app.handleFallback(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if !strings.Contains(w.Body.String(), "Conference talks") {
+		t.Error("nested page should be rendered")
+	}
+}
+
+func TestBuildNav(t *testing.T) {
+	pages := []Page{
+		{Title: "Now", Slug: "now", Nav: true, NavOrder: 2},
+		{Title: "Hidden", Slug: "hidden"},
+		{Title: "About me", Slug: "about", Nav: true, NavTitle: "About"},
+		{Title: "Uses", Slug: "uses", Nav: true, NavOrder: 1},
+	}
+
+	var got []string
+	for _, item := range buildNav(pages) {
+		got = append(got, item.Title+"="+item.URL)
+	}
+	want := "Posts=/posts Projects=/projects About=/about Uses=/uses Now=/now Search=/search"
+	if strings.Join(got, " ") != want {
+		t.Errorf("nav = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestRenderIncludesNav(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	app.handleFallback(w, req)
+
+	if !strings.Contains(w.Body.String(), `<a href="/uses">Uses</a>`) {
+		t.Error("404 page should include nav links from content pages")
+	}
+}
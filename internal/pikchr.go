@@ -0,0 +1,790 @@
+package blog
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Pikchr diagrams are drawn by an interpreter for the core of the
+// language: objects placed one after another in the current direction,
+//
+//	box "Client"
+//	arrow "HTTP" above
+//	box "Server" fit
+//	down; arrow; DB: cylinder "Postgres"
+//
+// with the box, circle, ellipse, oval, cylinder, diamond, file, dot and
+// text shapes, and line, arrow, move and spline paths. Objects can be
+// labelled (DB: cylinder) and sized (wid, ht, rad, diam, fit); paths take
+// directions with lengths joined by then, and from, to and at positions:
+// a label or last/previous/1st/2nd... object, with an optional port like
+// .n or .se and an offset like + (0.5, 0). A path to or from a whole
+// object stops at its outline. Styles are dashed, dotted, thick, thin,
+// invis, color and fill, and ->, <- or <-> for arrowheads. Lengths are in
+// inches unless given in cm, mm, pt or px, or as a % of the default.
+
+// pikInch is the size of an inch: 96px, as in CSS, puts the default text
+// height close to the body font.
+const pikInch = 96
+
+var pikDefaults = map[string]struct{ w, h float64 }{
+	"box":      {0.75, 0.5},
+	"circle":   {0.5, 0.5},
+	"ellipse":  {0.75, 0.5},
+	"oval":     {1, 0.5},
+	"cylinder": {0.75, 0.75},
+	"diamond":  {1, 0.75},
+	"file":     {0.5, 0.75},
+	"dot":      {0.05, 0.05},
+	"text":     {0, 0},
+}
+
+// pikLineLength is the default length of a path segment, in inches.
+const pikLineLength = 0.5
+
+// pikMaxObjects caps a diagram's size; bigger ones are shown as source.
+const pikMaxObjects = 500
+
+var pikDirections = map[string]point{
+	"right": {1, 0}, "left": {-1, 0}, "up": {0, -1}, "down": {0, 1},
+}
+
+var pikColor = regexp.MustCompile(`^#?[A-Za-z0-9]+$`)
+
+type pikToken struct {
+	kind byte   // i identifier, s string, n number, o ordinal, l label, p punctuation, ; end of statement
+	text string // identifier, string or punctuation; a number's unit
+	num  float64
+	line int
+}
+
+type pikText struct {
+	s     string
+	above bool
+	below bool
+	align string // start, middle or end
+}
+
+type pikObject struct {
+	class        string
+	text         []pikText
+	w, h, rad    float64
+	center       point
+	start, end   point
+	pts          []point // paths only
+	style        string
+	thick, thin  bool
+	invis        bool
+	arrowStart   bool
+	arrowEnd     bool
+	stroke, fill string
+	isPath       bool
+	sized        bool
+}
+
+func (o *pikObject) port(name string) (point, bool) {
+	if name == "start" {
+		return o.start, true
+	}
+	if name == "end" {
+		return o.end, true
+	}
+	w, h := o.w/2, o.h/2
+	dw, dh := w, h
+	if o.class == "circle" || o.class == "ellipse" {
+		// the diagonal ports sit on the outline
+		dw, dh = w*math.Sqrt(0.5), h*math.Sqrt(0.5)
+	}
+	offsets := map[string]point{
+		"n": {0, -h}, "north": {0, -h}, "top": {0, -h}, "t": {0, -h},
+		"s": {0, h}, "south": {0, h}, "bottom": {0, h}, "bot": {0, h}, "b": {0, h},
+		"e": {w, 0}, "east": {w, 0}, "right": {w, 0},
+		"w": {-w, 0}, "west": {-w, 0}, "left": {-w, 0},
+		"ne": {dw, -dh}, "nw": {-dw, -dh}, "se": {dw, dh}, "sw": {-dw, dh},
+		"c": {}, "center": {},
+	}
+	off, ok := offsets[name]
+	return o.center.add(off), ok
+}
+
+// outline returns where the line from the object's center towards p
+// crosses its outline.
+func (o *pikObject) outline(p point) point {
+	d := p.sub(o.center)
+	if o.isPath || (d.X == 0 && d.Y == 0) || o.w == 0 || o.h == 0 {
+		return o.center
+	}
+	a, b := o.w/2, o.h/2
+	var t float64
+	switch o.class {
+	case "circle", "ellipse", "dot":
+		t = 1 / math.Hypot(d.X/a, d.Y/b)
+	case "diamond":
+		t = 1 / (math.Abs(d.X)/a + math.Abs(d.Y)/b)
+	default:
+		t = math.Inf(1)
+		if d.X != 0 {
+			t = a / math.Abs(d.X)
+		}
+		if d.Y != 0 {
+			t = min(t, b/math.Abs(d.Y))
+		}
+	}
+	return o.center.add(d.scale(min(t, 1)))
+}
+
+type pikParser struct {
+	toks    []pikToken
+	pos     int
+	objects []*pikObject
+	labels  map[string]*pikObject
+	dir     point
+	cursor  point
+}
+
+func layoutPikchr(ctx context.Context, src string) (string, error) {
+	toks, err := pikLex(src)
+	if err != nil {
+		return "", err
+	}
+	p := &pikParser{toks: toks, labels: make(map[string]*pikObject), dir: pikDirections["right"]}
+	for p.pos < len(p.toks) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := p.statement(); err != nil {
+			return "", err
+		}
+	}
+	if len(p.objects) == 0 {
+		return "", fmt.Errorf("empty diagram")
+	}
+	return p.draw(), nil
+}
+
+func pikLex(src string) ([]pikToken, error) {
+	var toks []pikToken
+	line := 1
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case r == '\n' || r == ';':
+			toks = append(toks, pikToken{kind: ';', line: line})
+			if r == '\n' {
+				line++
+			}
+			i++
+		case r == '\\' && i+1 < len(rs) && rs[i+1] == '\n':
+			// a statement continues on the next line
+			line++
+			i += 2
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || (r == '/' && i+1 < len(rs) && rs[i+1] == '/'):
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			j := i + 2
+			for j+1 < len(rs) && (rs[j] != '*' || rs[j+1] != '/') {
+				if rs[j] == '\n' {
+					line++
+				}
+				j++
+			}
+			if j+1 >= len(rs) {
+				return nil, fmt.Errorf("line %d: unclosed comment", line)
+			}
+			i = j + 2
+		case r == '"':
+			var s strings.Builder
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"' && rs[j] != '\n'; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) && (rs[j+1] == '"' || rs[j+1] == '\\') {
+					j++
+				}
+				s.WriteRune(rs[j])
+			}
+			if j == len(rs) || rs[j] != '"' {
+				return nil, fmt.Errorf("line %d: unclosed string", line)
+			}
+			toks = append(toks, pikToken{kind: 's', text: s.String(), line: line})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			n, err := strconv.ParseFloat(string(rs[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad number %q", line, string(rs[i:j]))
+			}
+			k := j
+			for k < len(rs) && (unicode.IsLetter(rs[k]) || rs[k] == '%') {
+				k++
+			}
+			unit := string(rs[j:k])
+			switch unit {
+			case "st", "nd", "rd", "th":
+				toks = append(toks, pikToken{kind: 'o', num: n, line: line})
+			case "", "in", "cm", "mm", "pt", "px", "%":
+				toks = append(toks, pikToken{kind: 'n', num: n, text: unit, line: line})
+			default:
+				return nil, fmt.Errorf("line %d: unknown unit %q", line, unit)
+			}
+			i = k
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			word := string(rs[i:j])
+			if j < len(rs) && rs[j] == ':' && unicode.IsUpper(r) {
+				toks = append(toks, pikToken{kind: 'l', text: word, line: line})
+				j++
+			} else {
+				toks = append(toks, pikToken{kind: 'i', text: word, line: line})
+			}
+			i = j
+		default:
+			if op := pikOperator(rs[i:]); op != "" {
+				toks = append(toks, pikToken{kind: 'p', text: op, line: line})
+				i += len(op)
+				continue
+			}
+			if !strings.ContainsRune(".,()+-*", r) {
+				return nil, fmt.Errorf("line %d: unexpected %q", line, r)
+			}
+			toks = append(toks, pikToken{kind: 'p', text: string(r), line: line})
+			i++
+		}
+	}
+	return toks, nil
+}
+
+// pikOperator returns the arrow operator rs starts with, if any.
+func pikOperator(rs []rune) string {
+	for _, op := range []string{"<->", "->", "<-"} {
+		if strings.HasPrefix(string(rs[:min(len(op), len(rs))]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *pikParser) peek() pikToken {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	end := pikToken{kind: ';'}
+	if len(p.toks) > 0 {
+		end.line = p.toks[len(p.toks)-1].line
+	}
+	return end
+}
+
+func (p *pikParser) next() pikToken {
+	t := p.peek()
+	if p.pos < len(p.toks) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *pikParser) errorf(t pikToken, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+func (p *pikParser) unexpected(t pikToken) error {
+	switch t.kind {
+	case ';':
+		return p.errorf(t, "unexpected end of statement")
+	case 'n':
+		return p.errorf(t, "unexpected number %g%s", t.num, t.text)
+	case 'o':
+		return p.errorf(t, "unexpected ordinal %g", t.num)
+	}
+	return p.errorf(t, "unexpected %q", t.text)
+}
+
+func (p *pikParser) statement() error {
+	t := p.next()
+	if t.kind == ';' {
+		return nil
+	}
+	if d, ok := pikDirections[t.text]; ok && t.kind == 'i' && p.peek().kind == ';' {
+		p.dir = d
+		return nil
+	}
+
+	label := ""
+	if t.kind == 'l' {
+		label = t.text
+		t = p.next()
+	}
+	o := &pikObject{}
+	switch {
+	case t.kind == 's':
+		o.class = "text"
+		p.pos--
+	case t.kind == 'i' && (t.text == "line" || t.text == "arrow" || t.text == "move" || t.text == "spline"):
+		o.class, o.isPath = t.text, true
+		o.arrowEnd = t.text == "arrow"
+		o.invis = t.text == "move"
+	default:
+		if _, ok := pikDefaults[t.text]; !ok || t.kind != 'i' {
+			return p.unexpected(t)
+		}
+		o.class = t.text
+	}
+	if !o.isPath {
+		def := pikDefaults[o.class]
+		o.w, o.h = def.w*pikInch, def.h*pikInch
+	}
+
+	var segs []point
+	var from, to, at *point
+	var fromObj, toObj *pikObject
+	for {
+		t := p.next()
+		if t.kind == ';' {
+			break
+		}
+		switch {
+		case t.kind == 's':
+			o.text = append(o.text, pikText{s: t.text, align: "middle"})
+		case t.kind == 'p' && (t.text == "->" || t.text == "<-" || t.text == "<->"):
+			o.arrowStart = t.text != "->"
+			o.arrowEnd = t.text != "<-"
+		case t.kind != 'i':
+			return p.unexpected(t)
+		case t.text == "above" || t.text == "below" || t.text == "ljust" || t.text == "rjust" ||
+			t.text == "center" || t.text == "bold" || t.text == "italic" || t.text == "big" || t.text == "small" || t.text == "aligned":
+			if len(o.text) == 0 {
+				return p.errorf(t, "%s with no text before it", t.text)
+			}
+			last := &o.text[len(o.text)-1]
+			switch t.text {
+			case "above":
+				last.above = true
+			case "below":
+				last.below = true
+			case "ljust":
+				last.align = "start"
+			case "rjust":
+				last.align = "end"
+			}
+		case t.text == "wid" || t.text == "width" || t.text == "ht" || t.text == "height" ||
+			t.text == "rad" || t.text == "radius" || t.text == "diam" || t.text == "diameter":
+			n := p.next()
+			if n.kind != 'n' {
+				return p.unexpected(n)
+			}
+			switch t.text {
+			case "wid", "width":
+				o.w = pikLength(n, o.w)
+			case "ht", "height":
+				o.h = pikLength(n, o.h)
+			case "diam", "diameter":
+				o.w = pikLength(n, o.w)
+				o.h = o.w
+			default:
+				if o.class == "box" || o.class == "file" {
+					o.rad = pikLength(n, 0.1*pikInch)
+					break
+				}
+				o.w = 2 * pikLength(n, o.w/2)
+				o.h = o.w
+			}
+			o.sized = true
+		case t.text == "fit":
+			o.sized = false
+		case t.text == "then" || t.text == "chop" || t.text == "same" || t.text == "close" || t.text == "solid":
+		case t.text == "dashed" || t.text == "dotted":
+			o.style = t.text
+			if p.peek().kind == 'n' {
+				p.next()
+			}
+		case t.text == "thick":
+			o.thick = true
+		case t.text == "thin":
+			o.thin = true
+		case t.text == "invis" || t.text == "invisible":
+			o.invis = true
+		case t.text == "color" || t.text == "colour" || t.text == "fill":
+			c := p.next()
+			if (c.kind != 'i' && c.kind != 's') || !pikColor.MatchString(c.text) {
+				return p.errorf(c, "bad color")
+			}
+			if t.text == "fill" {
+				o.fill = c.text
+			} else {
+				o.stroke = c.text
+			}
+		case t.text == "from" || t.text == "to" || t.text == "at":
+			pt, obj, err := p.position()
+			if err != nil {
+				return err
+			}
+			switch t.text {
+			case "from":
+				from, fromObj = &pt, obj
+			case "to":
+				to, toObj = &pt, obj
+			default:
+				at = &pt
+			}
+		default:
+			d, ok := pikDirections[t.text]
+			if !ok {
+				return p.unexpected(t)
+			}
+			if !o.isPath {
+				return p.errorf(t, "%s is only for lines, arrows and moves", t.text)
+			}
+			length := pikLineLength * pikInch
+			if n := p.peek(); n.kind == 'n' {
+				length = pikLength(p.next(), length)
+			}
+			segs = append(segs, d.scale(length))
+		}
+	}
+
+	if o.isPath {
+		p.placePath(o, segs, from, to, fromObj, toObj)
+	} else {
+		p.placeShape(o, at)
+	}
+	if len(p.objects) == pikMaxObjects {
+		return p.errorf(t, "over %d objects", pikMaxObjects)
+	}
+	p.objects = append(p.objects, o)
+	if label != "" {
+		p.labels[label] = o
+	}
+	return nil
+}
+
+// pikLength converts a number to pixels; a percentage is of def.
+func pikLength(t pikToken, def float64) float64 {
+	switch t.text {
+	case "%":
+		return def * t.num / 100
+	case "cm":
+		return t.num * pikInch / 2.54
+	case "mm":
+		return t.num * pikInch / 25.4
+	case "pt":
+		return t.num * pikInch / 72
+	case "px":
+		return t.num
+	}
+	return t.num * pikInch
+}
+
+// position parses a place: an object with an optional port, or a point
+// in inches, then an optional offset. It also returns the object when
+// the position is a whole one, for paths to stop at its outline.
+func (p *pikParser) position() (point, *pikObject, error) {
+	var pt point
+	var whole *pikObject
+	t := p.peek()
+	if t.kind == 'p' && t.text == "(" {
+		var err error
+		if pt, err = p.coords(); err != nil {
+			return pt, nil, err
+		}
+	} else {
+		o, err := p.objectRef()
+		if err != nil {
+			return pt, nil, err
+		}
+		pt, whole = o.center, o
+		if dot := p.peek(); dot.kind == 'p' && dot.text == "." {
+			p.next()
+			name := p.next()
+			port, ok := o.port(name.text)
+			if name.kind != 'i' || !ok {
+				return pt, nil, p.errorf(name, "unknown port %q", name.text)
+			}
+			pt, whole = port, nil
+		}
+	}
+	for {
+		op := p.peek()
+		if op.kind != 'p' || (op.text != "+" && op.text != "-") {
+			return pt, whole, nil
+		}
+		p.next()
+		off, err := p.coords()
+		if err != nil {
+			return pt, nil, err
+		}
+		if op.text == "-" {
+			off = off.scale(-1)
+		}
+		pt, whole = pt.add(off), nil
+	}
+}
+
+// coords parses (x, y) in inches, with y pointing up as in pikchr.
+func (p *pikParser) coords() (point, error) {
+	var v [2]float64
+	for i, want := range []string{"(", ",", ")"} {
+		if t := p.next(); t.kind != 'p' || t.text != want {
+			return point{}, p.unexpected(t)
+		}
+		if i == 2 {
+			break
+		}
+		sign := 1.0
+		if t := p.peek(); t.kind == 'p' && t.text == "-" {
+			p.next()
+			sign = -1
+		}
+		n := p.next()
+		if n.kind != 'n' {
+			return point{}, p.unexpected(n)
+		}
+		v[i] = sign * pikLength(n, 0)
+	}
+	return point{v[0], -v[1]}, nil
+}
+
+// objectRef parses Label, previous, or last/1st/2nd... with an optional
+// class.
+func (p *pikParser) objectRef() (*pikObject, error) {
+	t := p.next()
+	switch {
+	case t.kind == 'i' && t.text == "previous":
+		if len(p.objects) == 0 {
+			return nil, p.errorf(t, "no previous object")
+		}
+		return p.objects[len(p.objects)-1], nil
+	case t.kind == 'i' && t.text == "last", t.kind == 'o':
+		class := ""
+		if c := p.peek(); c.kind == 'i' {
+			if _, ok := pikDefaults[c.text]; ok || c.text == "line" || c.text == "arrow" || c.text == "move" || c.text == "spline" {
+				class = c.text
+				p.next()
+			}
+		}
+		var matches []*pikObject
+		for _, o := range p.objects {
+			if class == "" || o.class == class {
+				matches = append(matches, o)
+			}
+		}
+		i := len(matches) - 1
+		if t.kind == 'o' {
+			i = int(t.num) - 1
+		}
+		if i < 0 || i >= len(matches) {
+			return nil, p.errorf(t, "no such %s", strings.TrimSpace("object "+class))
+		}
+		return matches[i], nil
+	case t.kind == 'i' && t.text != "" && unicode.IsUpper([]rune(t.text)[0]):
+		o, ok := p.labels[t.text]
+		if !ok {
+			return nil, p.errorf(t, "unknown label %q", t.text)
+		}
+		return o, nil
+	}
+	return nil, p.unexpected(t)
+}
+
+func (p *pikParser) placeShape(o *pikObject, at *point) {
+	tw, th := 0.0, 0.0
+	if len(o.text) > 0 {
+		lines := make([]string, len(o.text))
+		for i, t := range o.text {
+			lines[i] = t.s
+		}
+		tw, th = labelSize(lines)
+	}
+	switch {
+	case o.class == "text":
+		o.w, o.h = max(o.w, tw+4), max(o.h, th+4)
+	case o.class == "circle":
+		if !o.sized {
+			d := max(o.w, math.Hypot(tw, th)+8)
+			o.w, o.h = d, d
+		}
+	case !o.sized:
+		// grow to fit the text, as fit does
+		scale := 1.0
+		if o.class == "diamond" || o.class == "ellipse" {
+			scale = 1.5
+		}
+		o.w, o.h = max(o.w, (tw+16)*scale), max(o.h, (th+12)*scale)
+	}
+
+	if at != nil {
+		o.center = *at
+	} else {
+		half := point{p.dir.X * o.w / 2, p.dir.Y * o.h / 2}
+		o.center = p.cursor.add(half)
+	}
+	half := point{p.dir.X * o.w / 2, p.dir.Y * o.h / 2}
+	o.start, o.end = o.center.sub(half), o.center.add(half)
+	p.cursor = o.end
+}
+
+func (p *pikParser) placePath(o *pikObject, segs []point, from, to *point, fromObj, toObj *pikObject) {
+	start := p.cursor
+	if from != nil {
+		start = *from
+	}
+	pts := []point{start}
+	for _, s := range segs {
+		pts = append(pts, pts[len(pts)-1].add(s))
+	}
+	if to != nil {
+		pts = append(pts, *to)
+	}
+	if len(pts) == 1 {
+		pts = append(pts, start.add(p.dir.scale(pikLineLength*pikInch)))
+	}
+	if fromObj != nil {
+		pts[0] = fromObj.outline(pts[1])
+	}
+	if toObj != nil {
+		n := len(pts)
+		pts[n-1] = toObj.outline(pts[n-2])
+	}
+
+	o.pts = pts
+	o.start, o.end = pts[0], pts[len(pts)-1]
+	minX, minY, maxX, maxY := pts[0].X, pts[0].Y, pts[0].X, pts[0].Y
+	for _, pt := range pts {
+		minX, minY = min(minX, pt.X), min(minY, pt.Y)
+		maxX, maxY = max(maxX, pt.X), max(maxY, pt.Y)
+	}
+	o.center = point{(minX + maxX) / 2, (minY + maxY) / 2}
+	o.w, o.h = maxX-minX, maxY-minY
+
+	// later objects carry on in the direction the path ended in
+	last := pts[len(pts)-1].sub(pts[len(pts)-2])
+	for _, d := range pikDirections {
+		if l := math.Hypot(last.X, last.Y); l > 0 && last.scale(1/l).eq(d) {
+			p.dir = d
+		}
+	}
+	p.cursor = o.end
+}
+
+func (p *pikParser) draw() string {
+	c := &svgCanvas{}
+	for _, o := range p.objects {
+		attrs := lineStyle(o.style)
+		if o.thick {
+			attrs += ` stroke-width="3"`
+		} else if o.thin {
+			attrs += ` stroke-width="0.75"`
+		}
+		if o.stroke != "" {
+			attrs += ` stroke="` + o.stroke + `"`
+		}
+		if o.fill != "" {
+			attrs += ` fill="` + o.fill + `"`
+		}
+		if o.invis {
+			attrs = ` stroke="none"`
+		}
+
+		x, y, w, h := o.center.X-o.w/2, o.center.Y-o.h/2, o.w, o.h
+		switch o.class {
+		case "box":
+			c.rect(x, y, w, h, o.rad, attrs)
+		case "oval":
+			c.rect(x, y, w, h, min(w, h)/2, attrs)
+		case "circle", "ellipse":
+			c.ellipse(o.center, w/2, h/2, attrs)
+		case "dot":
+			c.ellipse(o.center, w/2, h/2, ` fill="currentColor" stroke="none"`)
+		case "diamond":
+			c.polygon([]point{{o.center.X, y}, {x + w, o.center.Y}, {o.center.X, y + h}, {x, o.center.Y}}, attrs)
+		case "file":
+			fold := min(w, h) / 4
+			c.polygon([]point{{x, y}, {x + w - fold, y}, {x + w, y + fold}, {x + w, y + h}, {x, y + h}}, attrs)
+			if !o.invis {
+				c.polyline([]point{{x + w - fold, y}, {x + w - fold, y + fold}, {x + w, y + fold}}, attrs)
+			}
+		case "cylinder":
+			c.cylinder(x, y, w, h, min(h/6, 0.075*pikInch), attrs)
+		}
+		if o.isPath {
+			p.drawPath(c, o, attrs)
+			continue
+		}
+		if len(o.text) > 0 {
+			lines := make([]string, len(o.text))
+			for i, t := range o.text {
+				lines[i] = t.s
+			}
+			center := o.center
+			if o.class == "cylinder" {
+				center.Y += min(h/6, 0.075*pikInch) / 2
+			}
+			c.text(center, o.text[0].align, lines)
+		}
+	}
+	return c.svg("Diagram")
+}
+
+func (p *pikParser) drawPath(c *svgCanvas, o *pikObject, attrs string) {
+	pts := append([]point(nil), o.pts...)
+	n := len(pts)
+	if !o.invis {
+		if o.arrowEnd && !pts[n-1].eq(pts[n-2]) {
+			tip := pts[n-1]
+			pts[n-1] = tip.towards(pts[n-2], 8)
+			c.arrowHead(tip, pts[n-2])
+		}
+		if o.arrowStart && !pts[0].eq(pts[1]) {
+			tip := pts[0]
+			pts[0] = tip.towards(pts[1], 8)
+			c.arrowHead(tip, pts[1])
+		}
+		if o.class == "spline" {
+			attrs += ` stroke-linejoin="round"`
+		}
+		c.polyline(pts, attrs)
+	} else {
+		c.cover(o.center.X, o.center.Y, o.center.X, o.center.Y)
+	}
+
+	// text goes on the middle segment: one string above it, two around it
+	a, b := o.pts[(n-1)/2], o.pts[(n-1)/2+1]
+	if n%2 == 1 {
+		a, b = o.pts[n/2], o.pts[n/2]
+	}
+	mid := a.mid(b)
+	var above, below []string
+	for i, t := range o.text {
+		switch {
+		case t.above:
+			above = append(above, t.s)
+		case t.below:
+			below = append(below, t.s)
+		case i == 0:
+			above = append(above, t.s)
+		default:
+			below = append(below, t.s)
+		}
+	}
+	if len(above) > 0 {
+		_, h := labelSize(above)
+		c.text(point{mid.X, mid.Y - h/2 - 3}, "middle", above)
+	}
+	if len(below) > 0 {
+		_, h := labelSize(below)
+		c.text(point{mid.X, mid.Y + h/2 + 3}, "middle", below)
+	}
+}
@@ -1,6 +1,12 @@
 package blog
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
 
 func (app *App) handleHome(w http.ResponseWriter, r *http.Request) {
 	app.mu.RLock()
@@ -16,22 +22,41 @@ func (app *App) handleHome(w http.ResponseWriter, r *http.Request) {
 	app.render(w, "home", map[string]any{
 		"Posts":    posts[:limit],
 		"Projects": featured,
+		"Author":   siteAuthor,
+		"BaseURL":  app.cfg.BaseURL,
 	})
 }
 
 func (app *App) handlePostList(w http.ResponseWriter, r *http.Request) {
+	// Tag pages moved from /posts?tag= to /tags/{tag}
+	if tag := normalizeTag(r.URL.Query().Get("tag")); tag != "" {
+		http.Redirect(w, r, "/tags/"+url.PathEscape(tag), http.StatusMovedPermanently)
+		return
+	}
+
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			app.renderNotFound(w, r)
+			return
+		}
+		page = n
+	}
+
 	app.mu.RLock()
 	posts := app.visiblePosts()
 	app.mu.RUnlock()
 
-	tag := r.URL.Query().Get("tag")
-	if tag != "" {
-		posts = filterByTag(posts, tag)
+	posts, pager, ok := paginate(posts, page, postsPerPage)
+	if !ok {
+		app.renderNotFound(w, r)
+		return
 	}
 
 	app.render(w, "post_list", map[string]any{
 		"Posts":      posts,
-		"CurrentTag": tag,
+		"Pagination": pager,
 	})
 }
 
@@ -40,6 +65,8 @@ func (app *App) handlePost(w http.ResponseWriter, r *http.Request) {
 
 	app.mu.RLock()
 	post, ok := findPost(app.posts, slug)
+	series := newSeriesNav(app.visiblePosts(), post)
+	links := app.postLinks[slug]
 	app.mu.RUnlock()
 
 	if !ok {
@@ -55,10 +82,22 @@ func (app *App) handlePost(w http.ResponseWriter, r *http.Request) {
 
 	comments := app.commentsBySlug(slug)
 
+	w.Header().Set("Last-Modified", post.lastModified().UTC().Format(http.TimeFormat))
 	app.render(w, "post", map[string]any{
 		"Post":     post,
 		"Comments": comments,
+		"Series":   series,
+		"Prev":     links.Prev,
+		"Next":     links.Next,
+		"Related":  links.Related,
+		"History":  app.cfg.RevisionHistory,
+		"Author":   siteAuthor,
 		"BaseURL":  app.cfg.BaseURL,
+		"OGImage":  ogImageURL(app.cfg.BaseURL, post.Image, post.Slug),
+		"JSONLD": []jsonLD{
+			postLD(app.cfg.BaseURL, post),
+			breadcrumbLD(app.cfg.BaseURL, crumb{"Home", "/"}, crumb{"Posts", "/posts"}, crumb{post.Title, "/posts/" + post.Slug}),
+		},
 	})
 }
 
@@ -73,10 +112,11 @@ func publicPosts(posts []Post) []Post {
 }
 
 func filterByTag(posts []Post, tag string) []Post {
+	tag = normalizeTag(tag)
 	var out []Post
 	for _, p := range posts {
 		for _, t := range p.Tags {
-			if t == tag {
+			if normalizeTag(t) == tag {
 				out = append(out, p)
 				break
 			}
@@ -100,3 +140,91 @@ func findPost(posts []Post, slug string) (Post, bool) {
 	}
 	return Post{}, false
 }
+
+// postLinks holds where a reader can go after finishing a post.
+type postLinks struct {
+	Prev    *Post // older
+	Next    *Post // newer
+	Related []Post
+}
+
+// similarLimit caps how many related posts are shown under an article.
+const similarLimit = 3
+
+// buildPostLinks computes previous/next and related posts for every post.
+// posts must be sorted newest first, as loadAllPosts returns them.
+func buildPostLinks(posts []Post) map[string]postLinks {
+	links := make(map[string]postLinks, len(posts))
+	for i, p := range posts {
+		var l postLinks
+		if i+1 < len(posts) {
+			l.Prev = &posts[i+1]
+		}
+		if i > 0 {
+			l.Next = &posts[i-1]
+		}
+		l.Related = similarPosts(posts, p, similarLimit)
+		links[p.Slug] = l
+	}
+	return links
+}
+
+// similarPosts ranks posts by shared tags and project, favouring recent ones.
+// Posts with nothing in common are never returned.
+func similarPosts(posts []Post, post Post, limit int) []Post {
+	type scored struct {
+		post  Post
+		score float64
+	}
+
+	var newest time.Time
+	for _, p := range posts {
+		if p.Date.After(newest) {
+			newest = p.Date
+		}
+	}
+
+	var candidates []scored
+	for _, p := range posts {
+		if p.Slug == post.Slug {
+			continue
+		}
+		score := float64(sharedTags(p.Tags, post.Tags))
+		if post.Project != "" && p.Project == post.Project {
+			score += 2
+		}
+		if score == 0 {
+			continue
+		}
+		// A post a year older than the newest counts half as much, two years a third
+		age := newest.Sub(p.Date).Hours() / (24 * 365)
+		score /= 1 + age
+		candidates = append(candidates, scored{p, score})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	var out []Post
+	for _, c := range candidates {
+		out = append(out, c.post)
+	}
+	return out
+}
+
+func sharedTags(a, b []string) int {
+	n := 0
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
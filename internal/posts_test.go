@@ -47,6 +47,11 @@ func TestFilterByTag(t *testing.T) {
 		t.Errorf("filterByTag(go) = %v, want a and c", got)
 	}
 
+	got = filterByTag(posts, "Go")
+	if len(got) != 2 {
+		t.Errorf("filterByTag(Go) returned %d posts, want 2 (case-insensitive)", len(got))
+	}
+
 	got = filterByTag(posts, "nonexistent")
 	if got != nil {
 		t.Errorf("filterByTag(nonexistent) = %v, want nil", got)
@@ -182,17 +187,16 @@ func TestHandlePostListHidesPrivateInProd(t *testing.T) {
 func TestHandlePostListFilterTag(t *testing.T) {
 	app := testApp(t)
 
-	req := httptest.NewRequest("GET", "/posts?tag=go", nil)
+	req := httptest.NewRequest("GET", "/posts?tag=Go", nil)
 	w := httptest.NewRecorder()
 
 	app.handlePostList(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMovedPermanently)
 	}
-	body := w.Body.String()
-	if !strings.Contains(body, "First Post") {
-		t.Error("filtered list should contain 'First Post' (tagged 'go')")
+	if loc := w.Header().Get("Location"); loc != "/tags/go" {
+		t.Errorf("Location = %q, want %q", loc, "/tags/go")
 	}
 }
 
@@ -260,3 +264,95 @@ func TestHandlePostPrivateVisibleLocally(t *testing.T) {
 	}
 }
 
+func TestHandlePostTOC(t *testing.T) {
+	app := testApp(t)
+	app.posts[0].TOC = []TOCEntry{
+		{ID: "intro", Title: "Intro", Children: []TOCEntry{{ID: "details", Title: "Details"}}},
+	}
+
+	req := httptest.NewRequest("GET", "/posts/first-post", nil)
+	req.SetPathValue("slug", "first-post")
+	w := httptest.NewRecorder()
+
+	app.handlePost(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="#intro">Intro</a>`) {
+		t.Error("post page should link to TOC entry")
+	}
+	if !strings.Contains(body, `<a href="#details">Details</a>`) {
+		t.Error("post page should render nested TOC entries")
+	}
+}
+
+func TestBuildPostLinks(t *testing.T) {
+	posts := []Post{
+		{Slug: "newest", Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "middle", Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "oldest", Date: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	links := buildPostLinks(posts)
+
+	mid := links["middle"]
+	if mid.Prev == nil || mid.Prev.Slug != "oldest" {
+		t.Errorf("middle.Prev = %v, want oldest", mid.Prev)
+	}
+	if mid.Next == nil || mid.Next.Slug != "newest" {
+		t.Errorf("middle.Next = %v, want newest", mid.Next)
+	}
+	if links["newest"].Next != nil {
+		t.Error("newest post should have no Next")
+	}
+	if links["oldest"].Prev != nil {
+		t.Error("oldest post should have no Prev")
+	}
+}
+
+func TestSimilarPosts(t *testing.T) {
+	posts := []Post{
+		{Slug: "current", Tags: []string{"go", "web", "sqlite"}, Project: "blog", Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "one-tag", Tags: []string{"go"}, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "two-tags", Tags: []string{"go", "web"}, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "same-project", Project: "blog", Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "old-two-tags", Tags: []string{"go", "web"}, Date: time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "unrelated", Tags: []string{"rust"}, Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	got := similarPosts(posts, posts[0], 3)
+	want := []string{"two-tags", "same-project", "one-tag"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d posts, want %d: %v", len(got), len(want), got)
+	}
+	for i, slug := range want {
+		if got[i].Slug != slug {
+			t.Errorf("got[%d] = %q, want %q", i, got[i].Slug, slug)
+		}
+	}
+
+	if got := similarPosts(posts, posts[5], 3); got != nil {
+		t.Errorf("similarPosts(unrelated) = %v, want nil", got)
+	}
+}
+
+func TestHandlePostLinks(t *testing.T) {
+	app := testApp(t)
+	older := Post{Title: "Older Post", Slug: "older-post", Tags: []string{"go"}}
+	app.postLinks = map[string]postLinks{
+		"first-post": {Prev: &older, Related: []Post{older}},
+	}
+
+	req := httptest.NewRequest("GET", "/posts/first-post", nil)
+	req.SetPathValue("slug", "first-post")
+	w := httptest.NewRecorder()
+
+	app.handlePost(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `rel="prev"`) {
+		t.Error("post page should link to the previous post")
+	}
+	if !strings.Contains(body, "Related posts") {
+		t.Error("post page should list related posts")
+	}
+}
@@ -2,16 +2,22 @@ package blog
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
 	"go.abhg.dev/goldmark/frontmatter"
+	"gopkg.in/yaml.v3"
 )
 
 type Project struct {
@@ -19,38 +25,243 @@ type Project struct {
 	Slug        string
 	Description string
 	Repo        string
+	Status      string
+	Order       int
+	Started     time.Time
+	Ended       time.Time
+	Links       ProjectLinks
+	Cover       string
+	Image       string
 	Featured    bool
 	Tags        []string
+	Aliases     []string
+	Extra       map[string]any
+	Pages       []Page
+	Changelog   []ChangelogEntry
 	Body        template.HTML
+	issues      contentIssues
+}
+
+// ChangelogEntry is one release from a project's changelog.yaml.
+type ChangelogEntry struct {
+	Version string
+	Date    time.Time
+	Title   string
+	Notes   template.HTML
+}
+
+// ID is the anchor for the entry on the changelog page: "v0.3.0" → "v0-3-0"
+func (e ChangelogEntry) ID() string {
+	return slugify(e.Version)
+}
+
+type changelogYAML struct {
+	Version string `yaml:"version"`
+	Date    string `yaml:"date"`
+	Title   string `yaml:"title"`
+	Notes   string `yaml:"notes"`
+}
+
+type ProjectLinks struct {
+	Docs     string `yaml:"docs"`
+	Homepage string `yaml:"homepage"`
+	Releases string `yaml:"releases"`
 }
 
 type projectFrontmatter struct {
-	Title       string   `yaml:"title"`
-	Description string   `yaml:"description"`
-	Repo        string   `yaml:"repo"`
-	Featured    bool     `yaml:"featured"`
-	Tags        []string `yaml:"tags"`
+	Title       string         `yaml:"title"`
+	Description string         `yaml:"description"`
+	Repo        string         `yaml:"repo"`
+	Status      string         `yaml:"status"`
+	Order       int            `yaml:"order"`
+	Started     string         `yaml:"started"`
+	Ended       string         `yaml:"ended"`
+	Links       ProjectLinks   `yaml:"links"`
+	Cover       string         `yaml:"cover"`
+	Image       string         `yaml:"image"`
+	Featured    bool           `yaml:"featured"`
+	Tags        []string       `yaml:"tags"`
+	Aliases     []string       `yaml:"aliases"`
+	Extra       map[string]any `yaml:"extra"`
 }
 
-func loadAllProjects(dir string, md goldmark.Markdown) ([]Project, error) {
+// projectStatuses are the allowed status values, in the order project_list
+// shows them. Projects without a status are active.
+var projectStatuses = []struct {
+	Status string
+	Label  string
+}{
+	{"active", "Active"},
+	{"maintained", "Maintained"},
+	{"archived", "Archived"},
+	{"idea", "Ideas"},
+}
+
+// projectStatusNames returns the allowed status values, for the schema.
+func projectStatusNames() []string {
+	names := make([]string, len(projectStatuses))
+	for i, s := range projectStatuses {
+		names[i] = s.Status
+	}
+	return names
+}
+
+// normalizeStatus lowercases a frontmatter status. Anything that isn't
+// one of projectStatuses counts as active, so a typo can't hide a project
+// from /projects; the schema check still reports it.
+func normalizeStatus(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	for _, s := range projectStatuses {
+		if s.Status == status {
+			return status
+		}
+	}
+	return "active"
+}
+
+type projectGroup struct {
+	Status   string
+	Label    string
+	Projects []Project
+}
+
+// loadAllProjects loads single-file projects (projects/tori.md) and
+// directory projects (projects/tori/index.md). A directory project can have
+// extra pages and a changelog.yaml next to its index.md.
+func loadAllProjects(dir string, md goldmark.Markdown) ([]Project, contentIssues, error) {
 	files, err := filepath.Glob(filepath.Join(dir, "projects", "*.md"))
 	if err != nil {
-		return nil, err
+		return nil, contentIssues{}, err
+	}
+	indexes, err := filepath.Glob(filepath.Join(dir, "projects", "*", "index.md"))
+	if err != nil {
+		return nil, contentIssues{}, err
 	}
+	files = append(files, indexes...)
 	if len(files) == 0 {
-		return nil, nil
+		return nil, contentIssues{}, nil
 	}
 
 	var projects []Project
+	var issues contentIssues
+	seen := make(map[string]string) // slug → file
 	for _, f := range files {
 		p, err := parseProject(f, md)
+		issues.add(p.issues)
 		if err != nil {
 			log.Printf("skipping project %s: %v", f, err)
 			continue
 		}
+		if prev, ok := seen[p.Slug]; ok {
+			issues.Frontmatter = append(issues.Frontmatter, lintIssue{
+				File: f,
+				Line: 1,
+				Msg:  fmt.Sprintf("duplicate slug %q (also used by %s)", p.Slug, prev),
+			})
+			continue
+		}
+		seen[p.Slug] = f
+		if filepath.Base(f) == "index.md" {
+			projectDir := filepath.Dir(f)
+			if p.Pages, err = loadProjectPages(projectDir, md); err != nil {
+				log.Printf("project %s pages: %v", p.Slug, err)
+			}
+			for _, sub := range p.Pages {
+				issues.add(sub.issues)
+			}
+			if p.Changelog, err = loadChangelog(filepath.Join(projectDir, "changelog.yaml"), md); err != nil {
+				log.Printf("project %s changelog: %v", p.Slug, err)
+			}
+			// the changelog route is matched first, so changelog.md is never served
+			isChangelog := func(sub Page) bool { return sub.Slug == "changelog" }
+			if i := slices.IndexFunc(p.Pages, isChangelog); i >= 0 && len(p.Changelog) > 0 {
+				issues.Frontmatter = append(issues.Frontmatter, lintIssue{
+					File: filepath.Join(projectDir, "changelog.md"),
+					Line: 1,
+					Msg:  fmt.Sprintf("page /projects/%s/changelog is shadowed by changelog.yaml", p.Slug),
+				})
+				p.Pages = slices.Delete(p.Pages, i, i+1)
+			}
+		}
 		projects = append(projects, p)
 	}
-	return projects, nil
+	sortProjects(projects)
+	return projects, issues, nil
+}
+
+// projectSlug derives a project's slug from its file: "projects/tori.md" and
+// "projects/tori/index.md" are both "tori".
+func projectSlug(path string) string {
+	if filepath.Base(path) == "index.md" {
+		return filepath.Base(filepath.Dir(path))
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".md")
+}
+
+func loadProjectPages(dir string, md goldmark.Markdown) ([]Page, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Page
+	for _, f := range files {
+		if filepath.Base(f) == "index.md" {
+			continue
+		}
+		p, err := parsePage(f, "project-page", md)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", f, err)
+		}
+		pages = append(pages, p)
+	}
+	return pages, nil
+}
+
+// loadChangelog reads a YAML list of releases, newest first. A missing file
+// is not an error.
+func loadChangelog(path string, md goldmark.Markdown) ([]ChangelogEntry, error) {
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []changelogYAML
+	if err := yaml.Unmarshal(src, &raw); err != nil {
+		return nil, err
+	}
+
+	entries := make([]ChangelogEntry, 0, len(raw))
+	for _, r := range raw {
+		if r.Version == "" {
+			return nil, fmt.Errorf("entry without version")
+		}
+		date, err := time.Parse("2006-01-02", r.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q, want YYYY-MM-DD", r.Version, r.Date)
+		}
+		// entries share a page, so their footnote ids need telling apart
+		ctx := parser.NewContext()
+		ctx.Set(footnotePrefixKey, slugify(r.Version)+"-")
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(r.Notes), &buf, parser.WithContext(ctx)); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.Version, err)
+		}
+		entries = append(entries, ChangelogEntry{
+			Version: r.Version,
+			Date:    date,
+			Title:   r.Title,
+			Notes:   template.HTML(buf.String()),
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.After(entries[j].Date)
+	})
+	return entries, nil
 }
 
 func parseProject(path string, md goldmark.Markdown) (Project, error) {
@@ -60,29 +271,43 @@ func parseProject(path string, md goldmark.Markdown) (Project, error) {
 	}
 
 	ctx := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
 	var buf bytes.Buffer
-	if err := md.Convert(src, &buf, parser.WithContext(ctx)); err != nil {
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
 		return Project{}, err
 	}
 
 	var meta projectFrontmatter
+	issues := checkDoc(path, "project", src, doc, ctx)
 	fm := frontmatter.Get(ctx)
 	if fm != nil {
 		if err := fm.Decode(&meta); err != nil {
-			return Project{}, err
+			return Project{issues: issues}, err
 		}
 	}
 
-	slug := strings.TrimSuffix(filepath.Base(path), ".md")
+	slug := projectSlug(path)
+	started, _ := time.Parse("2006-01-02", meta.Started)
+	ended, _ := time.Parse("2006-01-02", meta.Ended)
 
 	return Project{
 		Title:       meta.Title,
 		Slug:        slug,
 		Description: meta.Description,
 		Repo:        meta.Repo,
+		Status:      normalizeStatus(meta.Status),
+		Order:       meta.Order,
+		Started:     started,
+		Ended:       ended,
+		Links:       meta.Links,
+		Cover:       meta.Cover,
+		Image:       meta.Image,
 		Featured:    meta.Featured,
-		Tags:        meta.Tags,
+		Tags:        normalizeTags(meta.Tags),
+		Aliases:     meta.Aliases,
+		Extra:       meta.Extra,
 		Body:        template.HTML(buf.String()),
+		issues:      issues,
 	}, nil
 }
 
@@ -92,7 +317,7 @@ func (app *App) handleProjectList(w http.ResponseWriter, r *http.Request) {
 	app.mu.RUnlock()
 
 	app.render(w, "project_list", map[string]any{
-		"Projects": projects,
+		"Groups": groupByStatus(projects),
 	})
 }
 
@@ -109,10 +334,90 @@ func (app *App) handleProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ld := []jsonLD{
+		breadcrumbLD(app.cfg.BaseURL, crumb{"Home", "/"}, crumb{"Projects", "/projects"}, crumb{project.Title, "/projects/" + project.Slug}),
+	}
+	if code := projectLD(app.cfg.BaseURL, project); code != nil {
+		ld = append(ld, code)
+	}
+
 	app.render(w, "project", map[string]any{
 		"Project":      project,
 		"RelatedPosts": related,
 		"BaseURL":      app.cfg.BaseURL,
+		"OGImage":      ogImageURL(app.cfg.BaseURL, project.Image, "projects/"+project.Slug),
+		"JSONLD":       ld,
+	})
+}
+
+func (app *App) handleProjectPage(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	name := r.PathValue("page")
+
+	app.mu.RLock()
+	project, ok := findProject(app.projects, slug)
+	app.mu.RUnlock()
+
+	if !ok {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	if name == "changelog" && len(project.Changelog) > 0 {
+		app.render(w, "changelog", map[string]any{
+			"Project": project,
+			"BaseURL": app.cfg.BaseURL,
+		})
+		return
+	}
+
+	page, ok := findPage(project.Pages, name)
+	if !ok {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	app.render(w, "project_page", map[string]any{
+		"Project": project,
+		"Page":    page,
+		"BaseURL": app.cfg.BaseURL,
+	})
+}
+
+func (app *App) handleProjectChangelogFeed(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	app.mu.RLock()
+	project, ok := findProject(app.projects, slug)
+	app.mu.RUnlock()
+
+	if !ok || len(project.Changelog) == 0 {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	base := app.cfg.BaseURL + "/projects/" + project.Slug + "/changelog"
+	items := make([]rssItem, len(project.Changelog))
+	for i, e := range project.Changelog {
+		title := project.Title + " " + e.Version
+		if e.Title != "" {
+			title += ": " + e.Title
+		}
+		items[i] = rssItem{
+			Title:       title,
+			Link:        base + "#" + e.ID(),
+			Description: string(e.Notes),
+			PubDate:     e.Date.Format(time.RFC1123Z),
+			GUID:        base + "#" + e.ID(),
+		}
+	}
+
+	writeRSS(w, rssChannel{
+		Title:       project.Title + " changelog",
+		Link:        base,
+		Description: "Releases of " + project.Title,
+		LastBuild:   project.Changelog[0].Date.Format(time.RFC1123Z),
+		Items:       items,
 	})
 }
 
@@ -125,6 +430,45 @@ func findProject(projects []Project, slug string) (Project, bool) {
 	return Project{}, false
 }
 
+// sortProjects orders projects by explicit order first, then most recently
+// started, then title, so the list doesn't depend on glob order.
+func sortProjects(projects []Project) {
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		if (a.Order == 0) != (b.Order == 0) {
+			return a.Order != 0
+		}
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		if !a.Started.Equal(b.Started) {
+			return a.Started.After(b.Started)
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.Slug < b.Slug
+	})
+}
+
+// groupByStatus splits projects by status, keeping their order within each
+// group. Empty groups are left out.
+func groupByStatus(projects []Project) []projectGroup {
+	var groups []projectGroup
+	for _, s := range projectStatuses {
+		g := projectGroup{Status: s.Status, Label: s.Label}
+		for _, p := range projects {
+			if normalizeStatus(p.Status) == s.Status {
+				g.Projects = append(g.Projects, p)
+			}
+		}
+		if len(g.Projects) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
 func featuredProjects(projects []Project) []Project {
 	var out []Project
 	for _, p := range projects {
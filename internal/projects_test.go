@@ -3,8 +3,11 @@ package blog
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFindProject(t *testing.T) {
@@ -109,3 +112,249 @@ func TestHandleProjectNotFound(t *testing.T) {
 		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
 	}
 }
+
+func TestParseProjectDefaultStatus(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bare.md")
+	os.WriteFile(path, []byte("---\ntitle: Bare\n---\n"), 0644)
+
+	p, err := parseProject(path, newMarkdown())
+	if err != nil {
+		t.Fatalf("parseProject: %v", err)
+	}
+	if p.Status != "active" {
+		t.Errorf("Status = %q, want active", p.Status)
+	}
+}
+
+func TestParseProjectStatusCase(t *testing.T) {
+	dir := t.TempDir()
+	for body, want := range map[string]string{
+		"Archived": "archived",
+		"wip":      "active",
+	} {
+		path := filepath.Join(dir, want+".md")
+		os.WriteFile(path, []byte("---\ntitle: Cased\nstatus: "+body+"\n---\n"), 0644)
+
+		p, err := parseProject(path, newMarkdown())
+		if err != nil {
+			t.Fatalf("parseProject: %v", err)
+		}
+		if p.Status != want {
+			t.Errorf("status %q parsed as %q, want %q", body, p.Status, want)
+		}
+	}
+}
+
+func TestSortProjects(t *testing.T) {
+	projects := []Project{
+		{Slug: "b-old", Title: "B", Started: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "second", Title: "Second", Order: 2},
+		{Slug: "a-new", Title: "A", Started: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "first", Title: "First", Order: 1},
+		{Slug: "z-undated", Title: "Z"},
+		{Slug: "c-undated", Title: "C"},
+	}
+
+	sortProjects(projects)
+
+	want := []string{"first", "second", "a-new", "b-old", "c-undated", "z-undated"}
+	for i, slug := range want {
+		if projects[i].Slug != slug {
+			t.Errorf("projects[%d] = %q, want %q", i, projects[i].Slug, slug)
+		}
+	}
+}
+
+func TestGroupByStatus(t *testing.T) {
+	projects := []Project{
+		{Slug: "old", Status: "archived"},
+		{Slug: "live", Status: "active"},
+		{Slug: "unset"},
+		{Slug: "someday", Status: "idea"},
+		{Slug: "typo", Status: "wip"},
+	}
+
+	groups := groupByStatus(projects)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3 (no empty maintained group)", len(groups))
+	}
+	if groups[0].Status != "active" || len(groups[0].Projects) != 3 {
+		t.Errorf("groups[0] = %s with %d projects, want active with 3, including the unknown status", groups[0].Status, len(groups[0].Projects))
+	}
+	if groups[1].Status != "archived" || groups[2].Label != "Ideas" {
+		t.Errorf("groups = %s, %s; want archived then Ideas", groups[1].Status, groups[2].Label)
+	}
+}
+
+func TestHandleProjectListGroups(t *testing.T) {
+	app := testApp(t)
+	app.projects[1].Status = "archived"
+
+	req := httptest.NewRequest("GET", "/projects", nil)
+	w := httptest.NewRecorder()
+	app.handleProjectList(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "<h2>Archived</h2>") {
+		t.Error("project list should have an Archived heading")
+	}
+	if strings.Index(body, "Side Project") < strings.Index(body, "<h2>Archived</h2>") {
+		t.Error("archived project should be listed under the Archived heading")
+	}
+}
+
+func TestLoadAllProjectsDirectory(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"projects/single.md":           "---\ntitle: Single\n---\n",
+		"projects/tori/index.md":       "---\ntitle: Tori\n---\nOverview.\n",
+		"projects/tori/install.md":     "---\ntitle: Install\n---\nRun it.\n",
+		"projects/tori/changelog.yaml": "- version: v0.1.0\n  date: 2026-01-01\n  notes: First.\n- version: v0.2.0\n  date: 2026-03-01\n  title: Alerts\n  notes: Adds **alerts**.\n",
+	})
+
+	projects, _, err := loadAllProjects(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadAllProjects: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(projects))
+	}
+
+	tori, ok := findProject(projects, "tori")
+	if !ok {
+		t.Fatal("directory project should use the directory name as slug")
+	}
+	if len(tori.Pages) != 1 || tori.Pages[0].Slug != "install" {
+		t.Errorf("Pages = %+v, want [install]", tori.Pages)
+	}
+	if len(tori.Changelog) != 2 || tori.Changelog[0].Version != "v0.2.0" {
+		t.Fatalf("Changelog = %+v, want newest first", tori.Changelog)
+	}
+	if !strings.Contains(string(tori.Changelog[0].Notes), "<strong>alerts</strong>") {
+		t.Errorf("Notes = %q, want rendered markdown", tori.Changelog[0].Notes)
+	}
+	if id := tori.Changelog[0].ID(); id != "v0-2-0" {
+		t.Errorf("ID() = %q, want v0-2-0", id)
+	}
+}
+
+func TestLoadAllProjectsConflicts(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"projects/tori.md":             "---\ntitle: Tori\n---\n",
+		"projects/tori/index.md":       "---\ntitle: Tori again\n---\n",
+		"projects/kiln/index.md":       "---\ntitle: Kiln\n---\n",
+		"projects/kiln/changelog.md":   "---\ntitle: Changelog\n---\n",
+		"projects/kiln/changelog.yaml": "- version: v0.1.0\n  date: 2026-01-01\n",
+	})
+
+	projects, issues, err := loadAllProjects(dir, newMarkdown())
+	if err != nil {
+		t.Fatalf("loadAllProjects: %v", err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(projects))
+	}
+	if tori, _ := findProject(projects, "tori"); tori.Title != "Tori" {
+		t.Errorf("tori = %q, want the projects/tori.md project", tori.Title)
+	}
+	if kiln, _ := findProject(projects, "kiln"); len(kiln.Pages) != 0 {
+		t.Errorf("kiln pages = %+v, want changelog.md dropped", kiln.Pages)
+	}
+
+	var got []string
+	for _, i := range issues.Frontmatter {
+		rel, _ := filepath.Rel(dir, i.File)
+		i.File = filepath.ToSlash(rel)
+		got = append(got, i.String())
+	}
+	want := []string{
+		`projects/kiln/changelog.md:1: page /projects/kiln/changelog is shadowed by changelog.yaml`,
+		`projects/tori/index.md:1: duplicate slug "tori" (also used by ` + filepath.Join(dir, "projects", "tori.md") + `)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("issues:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLoadChangelogInvalidDate(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"changelog.yaml": "- version: v1\n  date: March\n",
+	})
+	if _, err := loadChangelog(filepath.Join(dir, "changelog.yaml"), newMarkdown()); err == nil {
+		t.Error("expected error for invalid date")
+	}
+	if entries, err := loadChangelog(filepath.Join(dir, "missing.yaml"), newMarkdown()); err != nil || entries != nil {
+		t.Errorf("missing changelog = %v, %v; want nil, nil", entries, err)
+	}
+}
+
+func TestHandleProjectPage(t *testing.T) {
+	app := testApp(t)
+	app.projects[0].Pages = []Page{{Slug: "install", Title: "Install", Body: "<p>Run it.</p>"}}
+	app.projects[0].Changelog = []ChangelogEntry{
+		{Version: "v1.0.0", Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Title: "Stable", Notes: "<p>Done.</p>"},
+	}
+
+	tests := []struct {
+		page string
+		code int
+		want string
+	}{
+		{"install", http.StatusOK, "Run it."},
+		{"changelog", http.StatusOK, `id="v1-0-0"`},
+		{"nope", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/projects/blog/"+tt.page, nil)
+		req.SetPathValue("slug", "blog")
+		req.SetPathValue("page", tt.page)
+		w := httptest.NewRecorder()
+		app.handleProjectPage(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.page, w.Code, tt.code)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: body should contain %q", tt.page, tt.want)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/projects/blog", nil)
+	req.SetPathValue("slug", "blog")
+	w := httptest.NewRecorder()
+	app.handleProject(w, req)
+	if !strings.Contains(w.Body.String(), `href="/projects/blog/install"`) {
+		t.Error("project page should link to its sub-pages")
+	}
+}
+
+func TestHandleProjectChangelogFeed(t *testing.T) {
+	app := testApp(t)
+	app.projects[0].Changelog = []ChangelogEntry{
+		{Version: "v1.0.0", Date: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Title: "Stable"},
+	}
+
+	req := httptest.NewRequest("GET", "/projects/blog/changelog.xml", nil)
+	req.SetPathValue("slug", "blog")
+	w := httptest.NewRecorder()
+	app.handleProjectChangelogFeed(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<title>Blog v1.0.0: Stable</title>") {
+		t.Errorf("feed missing release item:\n%s", body)
+	}
+	if !strings.Contains(body, "/projects/blog/changelog#v1-0-0") {
+		t.Error("feed item should link to the release anchor")
+	}
+
+	req = httptest.NewRequest("GET", "/projects/side-project/changelog.xml", nil)
+	req.SetPathValue("slug", "side-project")
+	w = httptest.NewRecorder()
+	app.handleProjectChangelogFeed(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("project without changelog: status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package blog
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// redirectRule is one line of content/redirects: "<from> <to>".
+type redirectRule struct {
+	From string
+	To   string
+	Line int
+}
+
+// loadRedirects reads content/redirects. Blank lines and lines starting with
+// # are ignored. A missing file is not an error.
+func loadRedirects(dir string) ([]redirectRule, error) {
+	src, err := os.ReadFile(filepath.Join(dir, "redirects"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseRedirects(src)
+}
+
+func parseRedirects(src []byte) ([]redirectRule, error) {
+	var rules []redirectRule
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"<from> <to>\", got %q", n, line)
+		}
+		if !strings.HasPrefix(fields[0], "/") {
+			return nil, fmt.Errorf("line %d: %q must be a path starting with /", n, fields[0])
+		}
+		rules = append(rules, redirectRule{From: fields[0], To: fields[1], Line: n})
+	}
+	return rules, sc.Err()
+}
+
+// aliasPath resolves an alias from frontmatter. A bare slug is relative to
+// the section the content lives in ("old-name" on a post is
+// /posts/old-name); anything starting with / is used as is.
+func aliasPath(section, alias string) string {
+	if strings.HasPrefix(alias, "/") {
+		return alias
+	}
+	return section + alias
+}
+
+// buildRedirects maps old paths to their current location. Frontmatter
+// aliases win over content/redirects, since they move with the content.
+func buildRedirects(posts []Post, pages []Page, projects []Project, rules []redirectRule) map[string]string {
+	redirects := make(map[string]string, len(rules))
+	for _, r := range rules {
+		redirects[r.From] = r.To
+	}
+	for _, p := range posts {
+		for _, a := range p.Aliases {
+			redirects[aliasPath("/posts/", a)] = "/posts/" + p.Slug
+		}
+	}
+	for _, p := range projects {
+		for _, a := range p.Aliases {
+			redirects[aliasPath("/projects/", a)] = "/projects/" + p.Slug
+		}
+	}
+	for _, p := range pages {
+		for _, a := range p.Aliases {
+			redirects[aliasPath("/", a)] = "/" + p.Slug
+		}
+	}
+	return redirects
+}
+
+// redirect sends a 301 if path has moved. It's checked only once a request
+// would otherwise 404, so a redirect can never hide live content.
+func (app *App) redirect(w http.ResponseWriter, r *http.Request) bool {
+	app.mu.RLock()
+	to, ok := app.redirects[r.URL.Path]
+	app.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+	if r.URL.RawQuery != "" && !strings.Contains(to, "?") {
+		to += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, to, http.StatusMovedPermanently)
+	return true
+}
+
+// migrateAliasedSlugs moves comments and notification records from a post's
+// old slugs to its current one, so a renamed post keeps its discussion and
+// isn't announced to subscribers a second time. Each alias is migrated
+// once and recorded in slug_migrations, and an alias that is still another
+// post's slug is left alone, as its redirect is.
+func migrateAliasedSlugs(db *sql.DB, posts []Post) {
+	live := make(map[string]bool, len(posts))
+	for _, p := range posts {
+		live[p.Slug] = true
+	}
+	for _, p := range posts {
+		for _, a := range p.Aliases {
+			if strings.HasPrefix(a, "/") || live[a] {
+				continue
+			}
+			if err := migrateSlug(db, a, p.Slug); err != nil {
+				log.Printf("migrating %s → %s: %v", a, p.Slug, err)
+			}
+		}
+	}
+}
+
+func migrateSlug(db *sql.DB, from, to string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT OR IGNORE INTO slug_migrations (old_slug, new_slug) VALUES (?, ?)", from, to)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err // already migrated
+	}
+	if _, err := tx.Exec("UPDATE comments SET post_slug = ? WHERE post_slug = ?", to, from); err != nil {
+		return fmt.Errorf("comments: %w", err)
+	}
+	// The old row is left behind if the new slug was already notified
+	if _, err := tx.Exec("UPDATE OR IGNORE notified_posts SET slug = ? WHERE slug = ?", to, from); err != nil {
+		return fmt.Errorf("notified_posts: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM notified_posts WHERE slug = ?", from); err != nil {
+		return fmt.Errorf("notified_posts: %w", err)
+	}
+	return tx.Commit()
+}
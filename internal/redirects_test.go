@@ -0,0 +1,147 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRedirects(t *testing.T) {
+	rules, err := parseRedirects([]byte("# moved in 2026\n/old /posts/new\n\n/feed https://example.com/rss.xml\n"))
+	if err != nil {
+		t.Fatalf("parseRedirects: %v", err)
+	}
+	if len(rules) != 2 || rules[0].From != "/old" || rules[0].To != "/posts/new" || rules[1].Line != 4 {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	for _, bad := range []string{"/only-from\n", "relative /posts/x\n", "/a /b /c\n"} {
+		if _, err := parseRedirects([]byte(bad)); err == nil {
+			t.Errorf("parseRedirects(%q) should fail", bad)
+		}
+	}
+}
+
+func TestBuildRedirects(t *testing.T) {
+	redirects := buildRedirects(
+		[]Post{{Slug: "new-name", Aliases: []string{"old-name", "/2019/old-url"}}},
+		[]Page{{Slug: "about", Aliases: []string{"me"}}},
+		[]Project{{Slug: "tori", Aliases: []string{"tori-cli"}}},
+		[]redirectRule{{From: "/posts/old-name", To: "/elsewhere"}, {From: "/hire", To: "/about"}},
+	)
+
+	want := map[string]string{
+		"/posts/old-name":    "/posts/new-name",
+		"/2019/old-url":      "/posts/new-name",
+		"/me":                "/about",
+		"/projects/tori-cli": "/projects/tori",
+		"/hire":              "/about",
+	}
+	if len(redirects) != len(want) {
+		t.Errorf("got %d redirects, want %d: %v", len(redirects), len(want), redirects)
+	}
+	for from, to := range want {
+		if redirects[from] != to {
+			t.Errorf("redirects[%q] = %q, want %q", from, redirects[from], to)
+		}
+	}
+}
+
+func TestRenderNotFoundRedirects(t *testing.T) {
+	app := testApp(t)
+	app.redirects = map[string]string{"/posts/old-post": "/posts/first-post"}
+
+	req := httptest.NewRequest("GET", "/posts/old-post?utm=x", nil)
+	req.SetPathValue("slug", "old-post")
+	w := httptest.NewRecorder()
+	app.handlePost(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMovedPermanently)
+	}
+	if loc := w.Header().Get("Location"); loc != "/posts/first-post?utm=x" {
+		t.Errorf("Location = %q, want /posts/first-post?utm=x", loc)
+	}
+}
+
+func TestMigrateAliasedSlugs(t *testing.T) {
+	app := testApp(t)
+	seedComments(t, app.db, []Comment{{PostSlug: "old-post", Author: "a", Body: "hi", Visible: true}})
+	app.db.Exec("INSERT INTO notified_posts (slug) VALUES ('old-post')")
+
+	migrateAliasedSlugs(app.db, []Post{{Slug: "first-post", Aliases: []string{"old-post"}}})
+
+	var comments, notified int
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'first-post'").Scan(&comments)
+	app.db.QueryRow("SELECT COUNT(*) FROM notified_posts WHERE slug = 'first-post'").Scan(&notified)
+	if comments != 1 || notified != 1 {
+		t.Errorf("after migration: %d comments, %d notified rows on new slug; want 1, 1", comments, notified)
+	}
+}
+
+func TestMigrateAliasedSlugsOnce(t *testing.T) {
+	app := testApp(t)
+	posts := []Post{{Slug: "first-post", Aliases: []string{"old-post"}}}
+	migrateAliasedSlugs(app.db, posts)
+
+	// a new post later takes the old slug; its comments must stay put
+	seedComments(t, app.db, []Comment{{PostSlug: "old-post", Author: "a", Body: "hi", Visible: true}})
+	migrateAliasedSlugs(app.db, posts)
+
+	var moved int
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'first-post'").Scan(&moved)
+	if moved != 0 {
+		t.Errorf("%d comments moved on the second reload, want 0", moved)
+	}
+}
+
+func TestMigrateAliasedSlugsSkipsLivePosts(t *testing.T) {
+	app := testApp(t)
+	seedComments(t, app.db, []Comment{{PostSlug: "foo", Author: "a", Body: "hi", Visible: true}})
+	app.db.Exec("INSERT INTO notified_posts (slug) VALUES ('foo')")
+
+	migrateAliasedSlugs(app.db, []Post{{Slug: "foo"}, {Slug: "bar", Aliases: []string{"foo"}}})
+
+	var comments, notified int
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'foo'").Scan(&comments)
+	app.db.QueryRow("SELECT COUNT(*) FROM notified_posts WHERE slug = 'foo'").Scan(&notified)
+	if comments != 1 || notified != 1 {
+		t.Errorf("live post foo has %d comments, %d notified rows; want 1, 1", comments, notified)
+	}
+}
+
+func TestRenamePost(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-old-name.md": "---\ntitle: Old Name\ndate: 2026-01-01\naliases: [older-name]\n---\nBody.\n",
+		"posts/2026-02-01-taken.md":    "---\ntitle: Taken\ndate: 2026-02-01\n---\n",
+	})
+
+	dst, err := renamePost(dir, "old-name", "Better Name")
+	if err != nil {
+		t.Fatalf("renamePost: %v", err)
+	}
+	if filepath.Base(dst) != "2026-01-01-better-name.md" {
+		t.Errorf("dst = %s, want 2026-01-01-better-name.md", dst)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "posts", "2026-01-01-old-name.md")); !os.IsNotExist(err) {
+		t.Error("old file should be removed")
+	}
+
+	post, err := parsePost(dst, newMarkdown())
+	if err != nil {
+		t.Fatalf("parsePost: %v", err)
+	}
+	if post.Title != "Better Name" {
+		t.Errorf("Title = %q, want Better Name", post.Title)
+	}
+	if strings.Join(post.Aliases, ",") != "older-name,old-name" {
+		t.Errorf("Aliases = %v, want [older-name old-name]", post.Aliases)
+	}
+
+	if _, err := renamePost(dir, "better-name", "Taken"); err == nil {
+		t.Error("renaming onto an existing slug should fail")
+	}
+}
@@ -0,0 +1,136 @@
+package blog
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// retraction is an entry in content/retracted.yaml, written by blog retract.
+type retraction struct {
+	Slug   string `yaml:"slug"`
+	Title  string `yaml:"title"`
+	Date   string `yaml:"date"`
+	Reason string `yaml:"reason,omitempty"`
+}
+
+// gonePost is a retracted post, served as 410 Gone.
+type gonePost struct {
+	Title  string
+	Date   time.Time
+	Reason string
+}
+
+func loadRetractions(dir string) ([]retraction, error) {
+	src, err := os.ReadFile(filepath.Join(dir, "retracted.yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []retraction
+	if err := yaml.Unmarshal(src, &list); err != nil {
+		return nil, err
+	}
+	for _, r := range list {
+		if r.Slug == "" {
+			return nil, fmt.Errorf("entry without slug")
+		}
+		if _, err := time.Parse("2006-01-02", r.Date); err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q, want YYYY-MM-DD", r.Slug, r.Date)
+		}
+	}
+	return list, nil
+}
+
+// trackPublished records every public post in published_posts and returns
+// the retracted posts that are gone. A retracted post's comments are hidden
+// the first time it's seen, so a comment an admin shows again stays shown.
+// Posts deleted without blog retract get a plain 404.
+func trackPublished(db *sql.DB, posts []Post, retracted []retraction) map[string]gonePost {
+	live := make(map[string]bool, len(posts))
+	for _, p := range posts {
+		live[p.Slug] = true
+		if _, err := db.Exec(`INSERT OR IGNORE INTO published_posts (slug, title) VALUES (?, ?)`, p.Slug, p.Title); err != nil {
+			log.Printf("tracking published post %s: %v", p.Slug, err)
+		}
+	}
+
+	gone := make(map[string]gonePost)
+	for _, r := range retracted {
+		if live[r.Slug] {
+			continue
+		}
+		date, _ := time.Parse("2006-01-02", r.Date)
+		g := gonePost{Title: r.Title, Date: date, Reason: r.Reason}
+		if g.Title == "" {
+			db.QueryRow(`SELECT title FROM published_posts WHERE slug = ?`, r.Slug).Scan(&g.Title)
+		}
+		gone[r.Slug] = g
+		if err := hideRetractedComments(db, r.Slug); err != nil {
+			log.Printf("hiding comments on retracted post %s: %v", r.Slug, err)
+		}
+	}
+	return gone
+}
+
+// hideRetractedComments hides slug's comments unless retracted_posts shows
+// it was already done.
+func hideRetractedComments(db *sql.DB, slug string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT OR IGNORE INTO retracted_posts (slug) VALUES (?)`, slug)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE comments SET visible = 0 WHERE post_slug = ?`, slug); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// renderGone serves 410 Gone for a removed post. It reports false if path
+// isn't one.
+func (app *App) renderGone(w http.ResponseWriter, r *http.Request) bool {
+	slug, ok := strings.CutPrefix(r.URL.Path, "/posts/")
+	if !ok {
+		return false
+	}
+
+	app.mu.RLock()
+	post, ok := app.gone[slug]
+	data := map[string]any{"Nav": app.nav, "Post": post}
+	app.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	var buf bytes.Buffer
+	if err := app.tmpls["410"].ExecuteTemplate(&buf, "base.html", data); err != nil {
+		log.Printf("410 template error: %v", err)
+		http.Error(w, "gone", http.StatusGone)
+		return true
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusGone)
+	buf.WriteTo(w)
+	return true
+}
@@ -0,0 +1,109 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrackPublished(t *testing.T) {
+	app := testApp(t)
+	seedComments(t, app.db, []Comment{
+		{PostSlug: "retracted-post", Author: "a", Body: "hi", Visible: true},
+		{PostSlug: "first-post", Author: "b", Body: "hello", Visible: true},
+	})
+
+	trackPublished(app.db, []Post{{Slug: "first-post", Title: "First"}, {Slug: "deleted-post", Title: "Deleted"}, {Slug: "untitled", Title: "Untitled Post"}}, nil)
+	retracted := []retraction{
+		{Slug: "retracted-post", Title: "Oops", Date: "2026-05-01", Reason: "Wrong numbers"},
+		{Slug: "untitled", Date: "2026-05-02"},
+	}
+	gone := trackPublished(app.db, []Post{{Slug: "first-post", Title: "First"}}, retracted)
+
+	if _, ok := gone["deleted-post"]; ok {
+		t.Error("a post deleted without blog retract should 404, not be gone")
+	}
+	if g := gone["retracted-post"]; g.Title != "Oops" || g.Reason != "Wrong numbers" {
+		t.Errorf("retracted-post = %+v, want gone with reason", g)
+	}
+	if g := gone["untitled"]; g.Title != "Untitled Post" {
+		t.Errorf("untitled = %+v, want the title it was published with", g)
+	}
+	if _, ok := gone["first-post"]; ok {
+		t.Error("live post should not be gone")
+	}
+
+	var hidden, visible int
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'retracted-post' AND visible = 0").Scan(&hidden)
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'first-post' AND visible = 1").Scan(&visible)
+	if hidden != 1 || visible != 1 {
+		t.Errorf("hidden = %d, visible = %d; want comments hidden only on the retracted post", hidden, visible)
+	}
+
+	// an admin shows the comment again; later reloads must leave it
+	app.db.Exec("UPDATE comments SET visible = 1 WHERE post_slug = 'retracted-post'")
+	trackPublished(app.db, []Post{{Slug: "first-post", Title: "First"}}, retracted)
+	app.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_slug = 'retracted-post' AND visible = 1").Scan(&visible)
+	if visible != 1 {
+		t.Error("reload should not hide a comment shown again after the retraction")
+	}
+}
+
+func TestHandlePostGone(t *testing.T) {
+	app := testApp(t)
+	app.gone = map[string]gonePost{
+		"oops": {Title: "Oops", Date: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Reason: "Wrong numbers"},
+	}
+
+	tests := []struct {
+		slug string
+		code int
+		want string
+	}{
+		{"oops", http.StatusGone, "Wrong numbers"},
+		{"never-existed", http.StatusNotFound, "404"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/posts/"+tt.slug, nil)
+		req.SetPathValue("slug", tt.slug)
+		w := httptest.NewRecorder()
+		app.handlePost(w, req)
+
+		if w.Code != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.slug, w.Code, tt.code)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: body should contain %q", tt.slug, tt.want)
+		}
+	}
+}
+
+func TestRetractPost(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-oops.md": "---\ntitle: Oops\ndate: 2026-01-01\n---\n",
+		"retracted.yaml":           "- slug: older\n  title: Older\n  date: 2026-02-01\n",
+	})
+
+	if err := retractPost(dir, "oops", "Wrong numbers", time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("retractPost: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "posts", "2026-01-01-oops.md")); !os.IsNotExist(err) {
+		t.Error("post file should be removed")
+	}
+
+	list, err := loadRetractions(dir)
+	if err != nil {
+		t.Fatalf("loadRetractions: %v", err)
+	}
+	if len(list) != 2 || list[1] != (retraction{Slug: "oops", Title: "Oops", Date: "2026-05-01", Reason: "Wrong numbers"}) {
+		t.Errorf("retractions = %+v", list)
+	}
+
+	if err := retractPost(dir, "oops", "", time.Now()); err == nil {
+		t.Error("retracting a missing post should fail")
+	}
+}
@@ -0,0 +1,211 @@
+package blog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindDate
+	kindBool
+	kindInt
+	kindList
+	kindMap
+)
+
+type fieldSpec struct {
+	Name     string
+	Kind     fieldKind
+	Required bool
+	Allowed  []string
+}
+
+// schema lists every frontmatter field a content type understands. Anything
+// else is reported as unknown, except under extra: which is free-form.
+type schema []fieldSpec
+
+var postSchema = schema{
+	{Name: "title", Kind: kindString, Required: true},
+	{Name: "date", Kind: kindDate, Required: true},
+	{Name: "updated", Kind: kindDate},
+	{Name: "tags", Kind: kindList},
+	{Name: "description", Kind: kindString},
+	{Name: "project", Kind: kindString},
+	{Name: "series", Kind: kindString},
+	{Name: "series_order", Kind: kindInt},
+	{Name: "toc", Kind: kindBool},
+	{Name: "sidenotes", Kind: kindBool},
+	{Name: "aliases", Kind: kindList},
+	{Name: "image", Kind: kindString},
+	{Name: "extra", Kind: kindMap},
+}
+
+var projectSchema = schema{
+	{Name: "title", Kind: kindString, Required: true},
+	{Name: "description", Kind: kindString},
+	{Name: "repo", Kind: kindString},
+	{Name: "status", Kind: kindString, Allowed: projectStatusNames()},
+	{Name: "order", Kind: kindInt},
+	{Name: "started", Kind: kindDate},
+	{Name: "ended", Kind: kindDate},
+	{Name: "links", Kind: kindMap},
+	{Name: "cover", Kind: kindString},
+	{Name: "image", Kind: kindString},
+	{Name: "featured", Kind: kindBool},
+	{Name: "tags", Kind: kindList},
+	{Name: "aliases", Kind: kindList},
+	{Name: "extra", Kind: kindMap},
+}
+
+var pageSchema = schema{
+	{Name: "title", Kind: kindString, Required: true},
+	{Name: "nav", Kind: kindBool},
+	{Name: "nav_title", Kind: kindString},
+	{Name: "nav_order", Kind: kindInt},
+	{Name: "updated", Kind: kindDate},
+	{Name: "aliases", Kind: kindList},
+	{Name: "extra", Kind: kindMap},
+}
+
+var projectPageSchema = schema{
+	{Name: "title", Kind: kindString, Required: true},
+	{Name: "extra", Kind: kindMap},
+}
+
+// schemas maps a lintDoc kind to its schema. Tag metadata is unchecked.
+var schemas = map[string]schema{
+	"post":         postSchema,
+	"project":      projectSchema,
+	"page":         pageSchema,
+	"project-page": projectPageSchema,
+}
+
+func (s schema) field(name string) (fieldSpec, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return fieldSpec{}, false
+}
+
+// check reports missing required fields, unknown fields and values of the
+// wrong type in d's frontmatter.
+func (s schema) check(d *lintDoc) []lintIssue {
+	var issues []lintIssue
+	report := func(line int, format string, args ...any) {
+		issues = append(issues, lintIssue{File: d.Path, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	for _, spec := range s {
+		f, ok := d.Fields[spec.Name]
+		if !ok || f.Value.Tag == "!!null" {
+			if spec.Required {
+				report(1, "missing required field %q", spec.Name)
+			}
+			continue
+		}
+		if msg := spec.checkValue(f.Value); msg != "" {
+			report(f.Line, "%s", msg)
+		}
+	}
+
+	var unknown []string
+	for name := range d.Fields {
+		if _, ok := s.field(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		msg := fmt.Sprintf("unknown field %q", name)
+		if guess := s.closest(name); guess != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", guess)
+		}
+		report(d.Fields[name].Line, "%s", msg)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+func (spec fieldSpec) checkValue(v *yaml.Node) string {
+	switch spec.Kind {
+	case kindString:
+		if v.Kind != yaml.ScalarNode {
+			return fmt.Sprintf("%q must be a string", spec.Name)
+		}
+		if len(spec.Allowed) > 0 && !contains(spec.Allowed, v.Value) {
+			return fmt.Sprintf("%q must be one of %s (got %q)", spec.Name, strings.Join(spec.Allowed, ", "), v.Value)
+		}
+	case kindDate:
+		if _, err := time.Parse("2006-01-02", v.Value); v.Kind != yaml.ScalarNode || err != nil {
+			return fmt.Sprintf("invalid date %q for %q, want YYYY-MM-DD", v.Value, spec.Name)
+		}
+	case kindBool:
+		if v.Kind != yaml.ScalarNode || v.Tag != "!!bool" {
+			return fmt.Sprintf("%q must be true or false", spec.Name)
+		}
+	case kindInt:
+		if v.Kind != yaml.ScalarNode || v.Tag != "!!int" {
+			return fmt.Sprintf("%q must be an integer", spec.Name)
+		}
+	case kindList:
+		if v.Kind != yaml.SequenceNode {
+			return fmt.Sprintf("%q must be a list, e.g. [go, web]", spec.Name)
+		}
+	case kindMap:
+		if v.Kind != yaml.MappingNode {
+			return fmt.Sprintf("%q must be a map of key: value pairs", spec.Name)
+		}
+	}
+	return ""
+}
+
+// closest returns the known field within two edits of name, if any, to catch
+// typos like "descripton".
+func (s schema) closest(name string) string {
+	best, bestDist := "", 3
+	for _, f := range s {
+		if d := editDistance(name, f.Name); d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package blog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lintDocFrom writes src to a temp file and parses it as kind.
+func lintDocFrom(t *testing.T, kind, src string) *lintDoc {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "doc.md")
+	os.WriteFile(path, []byte(src), 0644)
+	d, err := readLintDoc(path, kind, newMarkdown())
+	if err != nil {
+		t.Fatalf("readLintDoc: %v", err)
+	}
+	return d
+}
+
+func issueStrings(issues []lintIssue) []string {
+	out := make([]string, len(issues))
+	for i, issue := range issues {
+		out[i] = strings.TrimPrefix(issue.String(), issue.File+":")
+	}
+	return out
+}
+
+func TestSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		kind string
+		src  string
+		want []string
+	}{
+		{
+			name: "valid post",
+			kind: "post",
+			src:  "---\ntitle: Hi\ndate: 2026-01-01\ntags: [go]\nseries_order: 2\ntoc: true\nextra:\n  hero: wide\n---\n",
+		},
+		{
+			name: "typo and missing",
+			kind: "post",
+			src:  "---\ntitle: Hi\ndescripton: oops\n---\n",
+			want: []string{
+				`1: missing required field "date"`,
+				`3: unknown field "descripton" (did you mean "description"?)`,
+			},
+		},
+		{
+			name: "wrong types",
+			kind: "post",
+			src:  "---\ntitle: [a, b]\ndate: 2026-01-01\ntoc: yes please\nseries_order: first\nextra: nope\n---\n",
+			want: []string{
+				`2: "title" must be a string`,
+				`4: "toc" must be true or false`,
+				`5: "series_order" must be an integer`,
+				`6: "extra" must be a map of key: value pairs`,
+			},
+		},
+		{
+			name: "empty required",
+			kind: "page",
+			src:  "---\ntitle:\n---\n",
+			want: []string{`1: missing required field "title"`},
+		},
+		{
+			name: "project status",
+			kind: "project",
+			src:  "---\ntitle: tori\nstatus: done\nwebsite: x\n---\n",
+			want: []string{
+				`3: "status" must be one of active, maintained, archived, idea (got "done")`,
+				`4: unknown field "website"`,
+			},
+		},
+		{
+			name: "new project template",
+			kind: "project",
+			src:  "---\ntitle: \"x\"\ndescription: \"\"\nrepo: \"\"\nstatus: active\nfeatured: false\ntags: []\n---\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := lintDocFrom(t, tt.kind, tt.src)
+			got := issueStrings(schemas[tt.kind].check(d))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"title", "title", 0},
+		{"descripton", "description", 1},
+		{"tgas", "tags", 2},
+		{"", "date", 4},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestReloadStrictFrontmatter(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-hello.md": "---\ntitle: Hello\ndate: 2026-01-01\ndescripton: typo\n---\n",
+	})
+
+	app := testApp(t)
+	app.cfg.ContentDir = dir
+
+	if err := app.reload(); err != nil {
+		t.Fatalf("non-strict reload should only warn, got %v", err)
+	}
+	if len(app.posts) != 1 {
+		t.Fatalf("got %d posts after reload, want 1", len(app.posts))
+	}
+
+	app.cfg.StrictFrontmatter = true
+	err := app.reload()
+	if err == nil || !strings.Contains(err.Error(), `unknown field "descripton"`) {
+		t.Fatalf("strict reload error = %v, want unknown field error", err)
+	}
+}
+
+func TestReloadStrictMalformedFrontmatter(t *testing.T) {
+	dir := writeContent(t, map[string]string{
+		"posts/2026-01-01-hello.md":  "---\ntitle: Hello\ndate: 2026-01-01\n---\n",
+		"posts/2026-01-02-broken.md": "---\ntitle: [Broken\ndate: 2026-01-02\n---\n",
+	})
+
+	app := testApp(t)
+	app.cfg.ContentDir = dir
+
+	if err := app.reload(); err != nil {
+		t.Fatalf("non-strict reload should skip the post, got %v", err)
+	}
+	if len(app.posts) != 1 {
+		t.Fatalf("got %d posts after reload, want 1", len(app.posts))
+	}
+
+	app.cfg.StrictFrontmatter = true
+	err := app.reload()
+	if err == nil || !strings.Contains(err.Error(), "2026-01-02-broken.md:1: frontmatter: yaml:") {
+		t.Fatalf("strict reload error = %v, want the YAML error", err)
+	}
+}
+
+func TestParsePostExtra(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "extra.md")
+	os.WriteFile(path, []byte("---\ntitle: Extra\nextra:\n  hero: wide\n---\n"), 0644)
+
+	post, err := parsePost(path, newMarkdown())
+	if err != nil {
+		t.Fatalf("parsePost: %v", err)
+	}
+	if post.Extra["hero"] != "wide" {
+		t.Errorf("Extra = %v, want hero: wide", post.Extra)
+	}
+}
@@ -2,6 +2,7 @@ package blog
 
 import (
 	"database/sql"
+	"encoding/json"
 	"html"
 	"html/template"
 	"log"
@@ -102,7 +103,7 @@ func (app *App) handleSearch(w http.ResponseWriter, r *http.Request) {
 					log.Printf("search scan error: %v", err)
 					break
 				}
-					snippet = html.EscapeString(snippet)
+				snippet = html.EscapeString(snippet)
 				snippet = strings.ReplaceAll(snippet, "&lt;mark&gt;", "<mark>")
 				snippet = strings.ReplaceAll(snippet, "&lt;/mark&gt;", "</mark>")
 				sr.Snippet = template.HTML(snippet)
@@ -117,6 +118,70 @@ func (app *App) handleSearch(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+type searchSuggestion struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// suggestLimit caps how many titles the typeahead endpoint returns.
+const suggestLimit = 8
+
+// suggestQuery turns free text into an FTS5 prefix query over titles and tags.
+// Each word is quoted to prevent operator injection: "go web" → {title tags} : ("go"* "web"*)
+func suggestQuery(q string) string {
+	words := strings.Fields(q)
+	if len(words) == 0 {
+		return ""
+	}
+	terms := make([]string, len(words))
+	for i, w := range words {
+		terms[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+	}
+	return "{title tags} : (" + strings.Join(terms, " ") + ")"
+}
+
+func (app *App) handleSearchSuggest(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(q) > 100 {
+		q = q[:100]
+	}
+
+	if !app.suggestLimiter.allow(clientIP(r)) {
+		http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	suggestions := []searchSuggestion{}
+	if match := suggestQuery(q); match != "" && app.db != nil {
+		// Weight title matches above tag matches
+		rows, err := app.db.Query(
+			`SELECT slug, title, content_type FROM search_index
+			 WHERE search_index MATCH ? ORDER BY bm25(search_index, 0, 10, 5, 0, 0) LIMIT ?`,
+			match, suggestLimit,
+		)
+		if err != nil {
+			log.Printf("search suggest error: %v", err)
+		} else {
+			defer rows.Close()
+			for rows.Next() {
+				var slug, title, contentType string
+				if err := rows.Scan(&slug, &title, &contentType); err != nil {
+					log.Printf("search suggest scan error: %v", err)
+					break
+				}
+				suggestions = append(suggestions, searchSuggestion{
+					Title: title,
+					URL:   "/" + contentType + "s/" + slug,
+				})
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
 func (app *App) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if app.db == nil {
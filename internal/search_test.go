@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestStripTags(t *testing.T) {
@@ -122,3 +123,91 @@ func TestHandleHealth(t *testing.T) {
 		t.Errorf("expected ok status, got %s", w.Body.String())
 	}
 }
+
+func TestSuggestQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"   ", ""},
+		{"go", `{title tags} : ("go"*)`},
+		{"go web", `{title tags} : ("go"* "web"*)`},
+		{`say "hi"`, `{title tags} : ("say"* """hi"""*)`},
+		{"NEAR(a b)", `{title tags} : ("NEAR(a"* "b)"*)`},
+	}
+	for _, tt := range tests {
+		if got := suggestQuery(tt.in); got != tt.want {
+			t.Errorf("suggestQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHandleSearchSuggest(t *testing.T) {
+	app := testApp(t)
+	rebuildSearchIndex(app.db, publicPosts(app.posts), app.projects)
+
+	t.Run("prefix match", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/search/suggest?q=Fir", nil)
+		w := httptest.NewRecorder()
+		app.handleSearchSuggest(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if !strings.Contains(w.Body.String(), `{"title":"First Post","url":"/posts/first-post"}`) {
+			t.Errorf("expected First Post suggestion, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("matches tags", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/search/suggest?q=we", nil)
+		w := httptest.NewRecorder()
+		app.handleSearchSuggest(w, r)
+		if !strings.Contains(w.Body.String(), "First Post") {
+			t.Errorf("expected tag prefix match, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("ignores body", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/search/suggest?q=Hello", nil)
+		w := httptest.NewRecorder()
+		app.handleSearchSuggest(w, r)
+		if strings.TrimSpace(w.Body.String()) != "[]" {
+			t.Errorf("expected empty list, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/api/search/suggest", nil)
+		w := httptest.NewRecorder()
+		app.handleSearchSuggest(w, r)
+		if strings.TrimSpace(w.Body.String()) != "[]" {
+			t.Errorf("expected empty list, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestHandleSearchSuggestRateLimited(t *testing.T) {
+	app := testApp(t)
+	app.suggestLimiter = &rateLimiter{
+		requests: make(map[string][]time.Time),
+		limit:    1,
+		window:   time.Minute,
+	}
+
+	r := httptest.NewRequest("GET", "/api/search/suggest?q=go", nil)
+	w := httptest.NewRecorder()
+	app.handleSearchSuggest(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("first request: expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	app.handleSearchSuggest(w, r)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: expected 429, got %d", w.Code)
+	}
+}
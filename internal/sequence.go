@@ -0,0 +1,213 @@
+package blog
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Sequence diagrams are written one statement per line:
+//
+//	participant Browser
+//	participant "API server" as API
+//	Browser -> API: GET /posts
+//	API --> Browser: 200 OK
+//	note over API: cached for 5s
+//
+// Participants are also declared by their first message, left to right
+// in order of appearance. -> is a call and --> a dashed reply; a message
+// to its own sender loops back. Notes go over one or two participants, or
+// left of or right of one. Lines starting with # are comments.
+
+const (
+	seqBoxHeight = 32
+	seqRowHeight = 34
+	seqSelfWidth = 36
+	seqGap       = 28
+)
+
+var (
+	seqParticipant = regexp.MustCompile(`^participant\s+(?:"([^"]+)"|(.+?))(?:\s+as\s+(\S+))?$`)
+	seqMessage     = regexp.MustCompile(`^(.+?)\s*(-->|->)\s*([^:>\s-][^:]*?)\s*(?::\s*(.*))?$`)
+	seqNote        = regexp.MustCompile(`^note\s+(over|left of|right of)\s+([^:]+?)\s*:\s*(.*)$`)
+)
+
+type seqParticipantInfo struct {
+	label []string
+	x     float64
+	width float64
+}
+
+type seqStep struct {
+	kind     string // message or note
+	from, to int    // participants; to is from for notes over one
+	dashed   bool
+	place    string // over, left of or right of, for notes
+	label    []string
+}
+
+func layoutSequence(ctx context.Context, src string) (string, error) {
+	var parts []*seqParticipantInfo
+	index := make(map[string]int)
+	participant := func(name, label string) int {
+		if i, ok := index[name]; ok {
+			return i
+		}
+		index[name] = len(parts)
+		parts = append(parts, &seqParticipantInfo{label: splitLabel(label)})
+		return len(parts) - 1
+	}
+
+	var steps []seqStep
+	for i, line := range strings.Split(src, "\n") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := seqParticipant.FindStringSubmatch(line); m != nil {
+			label := m[1] + m[2]
+			name := m[3]
+			if name == "" {
+				name = label
+			}
+			participant(name, label)
+			continue
+		}
+		if m := seqNote.FindStringSubmatch(line); m != nil {
+			names := strings.Split(m[2], ",")
+			if len(names) > 2 || (len(names) == 2 && m[1] != "over") {
+				return "", fmt.Errorf("line %d: a note goes over at most two participants", i+1)
+			}
+			s := seqStep{kind: "note", place: m[1], label: splitLabel(m[3])}
+			s.from = participant(strings.TrimSpace(names[0]), strings.TrimSpace(names[0]))
+			s.to = s.from
+			if len(names) == 2 {
+				s.to = participant(strings.TrimSpace(names[1]), strings.TrimSpace(names[1]))
+			}
+			steps = append(steps, s)
+			continue
+		}
+		if m := seqMessage.FindStringSubmatch(line); m != nil {
+			steps = append(steps, seqStep{
+				kind:   "message",
+				from:   participant(m[1], m[1]),
+				to:     participant(m[3], m[3]),
+				dashed: m[2] == "-->",
+				label:  splitLabel(m[4]),
+			})
+			continue
+		}
+		return "", fmt.Errorf("line %d: expected a participant, message or note, got %q", i+1, line)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("no participants")
+	}
+
+	// Space the lifelines so every box, message and note fits.
+	for _, p := range parts {
+		w, _ := labelSize(p.label)
+		p.width = max(w+24, 80)
+	}
+	gaps := make([]float64, len(parts))
+	for i := 1; i < len(parts); i++ {
+		gaps[i] = (parts[i-1].width+parts[i].width)/2 + seqGap
+	}
+	// a label needs its width spread over the gaps its arrow crosses
+	need := func(from, to int, width float64) {
+		if from > to {
+			from, to = to, from
+		}
+		var have float64
+		for i := from + 1; i <= to; i++ {
+			have += gaps[i]
+		}
+		if have < width {
+			gaps[to] += width - have
+		}
+	}
+	for _, s := range steps {
+		w, _ := labelSize(s.label)
+		switch {
+		case s.kind == "message" && s.from == s.to:
+			if s.from+1 < len(parts) {
+				need(s.from, s.from+1, seqSelfWidth+w+16)
+			}
+		case s.kind == "message":
+			need(s.from, s.to, w+24)
+		case s.place == "right of" && s.from+1 < len(parts):
+			need(s.from, s.from+1, w+32)
+		case s.place == "left of" && s.from > 0:
+			need(s.from-1, s.from, w+32)
+		case s.from != s.to:
+			need(s.from, s.to, w+16-parts[s.from].width/2-parts[s.to].width/2)
+		}
+	}
+	x := parts[0].width / 2
+	for i, p := range parts {
+		x += gaps[i]
+		p.x = x
+	}
+
+	c := &svgCanvas{}
+	y := float64(seqBoxHeight) + 16
+	body := &svgCanvas{}
+	for _, s := range steps {
+		_, h := labelSize(s.label)
+		a, b := parts[s.from], parts[s.to]
+		attrs := ""
+		if s.dashed {
+			attrs = lineStyle("dashed")
+		}
+		switch {
+		case s.kind == "message" && s.from == s.to:
+			y += 8
+			top, bottom := point{a.x, y}, point{a.x, y + 16}
+			right := a.x + seqSelfWidth
+			body.polyline([]point{top, {right, y}, {right, bottom.Y}, {a.x + 9, bottom.Y}}, attrs)
+			body.arrowHead(bottom, point{right, bottom.Y})
+			body.text(point{right + 8, y + 8}, "start", s.label)
+			y += 16 + seqRowHeight - diagramLineGap
+		case s.kind == "message":
+			y += h
+			from, to := point{a.x, y}, point{b.x, y}
+			body.polyline([]point{from, to.towards(from, 8)}, attrs)
+			body.arrowHead(to, from)
+			body.text(point{from.X + (to.X-from.X)/2, y - h/2 - 4}, "middle", s.label)
+			y += seqRowHeight - diagramLineGap
+		default:
+			w, _ := labelSize(s.label)
+			var left, right float64
+			switch s.place {
+			case "left of":
+				left, right = a.x-w-24, a.x-8
+			case "right of":
+				left, right = a.x+8, a.x+w+24
+			default:
+				left, right = min(a.x, b.x), max(a.x, b.x)
+				center := (left + right) / 2
+				half := max((right-left)/2+16, w/2+8)
+				left, right = center-half, center+half
+			}
+			body.rect(left, y, right-left, h+12, 0, ` style="fill: var(--code-bg, Canvas)"`)
+			body.text(point{(left + right) / 2, y + 6 + h/2}, "middle", s.label)
+			y += h + 12 + seqRowHeight - diagramLineGap
+		}
+	}
+	y += 8
+
+	// lifelines first, so messages and notes are drawn over them
+	for _, p := range parts {
+		c.rect(p.x-p.width/2, 0, p.width, seqBoxHeight, 3, "")
+		c.text(point{p.x, seqBoxHeight / 2}, "middle", p.label)
+		c.polyline([]point{{p.x, seqBoxHeight}, {p.x, y}}, ` stroke-width="1"`+lineStyle("dashed"))
+	}
+	if body.used {
+		c.cover(body.minX, body.minY, body.maxX, body.maxY)
+	}
+	c.b.WriteString(body.b.String())
+	return c.svg("Sequence diagram"), nil
+}
@@ -0,0 +1,90 @@
+package blog
+
+import (
+	"net/http"
+	"sort"
+)
+
+// seriesNav is the series box shown on a post: every part in order, plus
+// the parts either side of the current post.
+type seriesNav struct {
+	Title string
+	Slug  string
+	Parts []Post
+	Prev  *Post
+	Next  *Post
+}
+
+func (app *App) handleSeries(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+
+	app.mu.RLock()
+	parts := seriesPosts(app.visiblePosts(), slug)
+	app.mu.RUnlock()
+
+	if len(parts) == 0 {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	app.render(w, "series", map[string]any{
+		"Title": parts[0].Series,
+		"Slug":  slug,
+		"Posts": parts,
+	})
+}
+
+// seriesPosts returns the posts in a series ordered by series_order. Posts
+// without an explicit order come after the numbered ones, oldest first.
+func seriesPosts(posts []Post, slug string) []Post {
+	if slug == "" {
+		return nil
+	}
+
+	var out []Post
+	for _, p := range posts {
+		if p.SeriesSlug == slug {
+			out = append(out, p)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if (a.SeriesOrder == 0) != (b.SeriesOrder == 0) {
+			return a.SeriesOrder != 0
+		}
+		if a.SeriesOrder != b.SeriesOrder {
+			return a.SeriesOrder < b.SeriesOrder
+		}
+		return a.Date.Before(b.Date)
+	})
+	return out
+}
+
+// newSeriesNav builds the series box for post. Returns nil if the post is
+// not part of a series.
+func newSeriesNav(posts []Post, post Post) *seriesNav {
+	parts := seriesPosts(posts, post.SeriesSlug)
+	if len(parts) == 0 {
+		return nil
+	}
+
+	nav := &seriesNav{
+		Title: post.Series,
+		Slug:  post.SeriesSlug,
+		Parts: parts,
+	}
+	for i, p := range parts {
+		if p.Slug != post.Slug {
+			continue
+		}
+		if i > 0 {
+			nav.Prev = &parts[i-1]
+		}
+		if i < len(parts)-1 {
+			nav.Next = &parts[i+1]
+		}
+		break
+	}
+	return nav
+}
@@ -0,0 +1,159 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func seriesTestPosts() []Post {
+	return []Post{
+		{Slug: "unordered", Title: "Unordered", SeriesSlug: "build-log", Series: "Build Log", Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Slug: "part-2", Title: "Part 2", SeriesSlug: "build-log", Series: "Build Log", SeriesOrder: 2},
+		{Slug: "other", Title: "Other"},
+		{Slug: "part-1", Title: "Part 1", SeriesSlug: "build-log", Series: "Build Log", SeriesOrder: 1},
+		{Slug: "secret-part", Title: "Secret Part", SeriesSlug: "build-log", Series: "Build Log", SeriesOrder: 3, Private: true},
+	}
+}
+
+func TestSeriesPosts(t *testing.T) {
+	got := seriesPosts(seriesTestPosts(), "build-log")
+	want := []string{"part-1", "part-2", "secret-part", "unordered"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d posts, want %d", len(got), len(want))
+	}
+	for i, slug := range want {
+		if got[i].Slug != slug {
+			t.Errorf("got[%d] = %q, want %q", i, got[i].Slug, slug)
+		}
+	}
+
+	if got := seriesPosts(seriesTestPosts(), ""); got != nil {
+		t.Errorf("seriesPosts(\"\") = %v, want nil", got)
+	}
+}
+
+func TestNewSeriesNav(t *testing.T) {
+	posts := publicPosts(seriesTestPosts())
+
+	part2, _ := findPost(posts, "part-2")
+	nav := newSeriesNav(posts, part2)
+	if nav == nil {
+		t.Fatal("newSeriesNav returned nil for series post")
+	}
+	if nav.Title != "Build Log" || len(nav.Parts) != 3 {
+		t.Errorf("nav = %q with %d parts, want Build Log with 3", nav.Title, len(nav.Parts))
+	}
+	if nav.Prev == nil || nav.Prev.Slug != "part-1" {
+		t.Errorf("Prev = %v, want part-1", nav.Prev)
+	}
+	if nav.Next == nil || nav.Next.Slug != "unordered" {
+		t.Errorf("Next = %v, want unordered (private part skipped)", nav.Next)
+	}
+
+	part1, _ := findPost(posts, "part-1")
+	first := newSeriesNav(posts, part1)
+	if first.Prev != nil {
+		t.Errorf("first part Prev = %v, want nil", first.Prev)
+	}
+
+	if newSeriesNav(posts, Post{Slug: "other"}) != nil {
+		t.Error("newSeriesNav should return nil for posts outside a series")
+	}
+}
+
+func TestHandleSeries(t *testing.T) {
+	app := testApp(t)
+	app.posts = seriesTestPosts()
+
+	req := httptest.NewRequest("GET", "/series/build-log", nil)
+	req.SetPathValue("slug", "build-log")
+	w := httptest.NewRecorder()
+	app.handleSeries(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Build Log") {
+		t.Error("series page should contain series title")
+	}
+	if strings.Index(body, "Part 1") > strings.Index(body, "Part 2") {
+		t.Error("series page should list Part 1 before Part 2")
+	}
+}
+
+func TestHandleSeriesHidesPrivateInProd(t *testing.T) {
+	app := testApp(t)
+	app.cfg.BaseURL = "https://thobiasn.dev"
+	app.posts = seriesTestPosts()
+
+	req := httptest.NewRequest("GET", "/series/build-log", nil)
+	req.SetPathValue("slug", "build-log")
+	w := httptest.NewRecorder()
+	app.handleSeries(w, req)
+
+	if strings.Contains(w.Body.String(), "Secret Part") {
+		t.Error("series page should not list private posts in prod")
+	}
+}
+
+func TestHandleSeriesNotFound(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/series/nope", nil)
+	req.SetPathValue("slug", "nope")
+	w := httptest.NewRecorder()
+	app.handleSeries(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandlePostSeriesBox(t *testing.T) {
+	app := testApp(t)
+	app.posts = seriesTestPosts()
+
+	req := httptest.NewRequest("GET", "/posts/part-1", nil)
+	req.SetPathValue("slug", "part-1")
+	w := httptest.NewRecorder()
+	app.handlePost(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="/series/build-log">Build Log</a>`) {
+		t.Error("post page should link to its series")
+	}
+	if !strings.Contains(body, `<strong aria-current="page">Part 1</strong>`) {
+		t.Error("post page should highlight the current part")
+	}
+	if !strings.Contains(body, `rel="next"`) {
+		t.Error("post page should link to the next part")
+	}
+}
+
+func TestHandlePostSeriesOwnsRel(t *testing.T) {
+	app := testApp(t)
+	app.posts = seriesTestPosts()
+	older := Post{Title: "Older Post", Slug: "older-post"}
+	newer := Post{Title: "Newer Post", Slug: "newer-post"}
+	app.postLinks = map[string]postLinks{"part-1": {Prev: &older, Next: &newer}}
+
+	req := httptest.NewRequest("GET", "/posts/part-1", nil)
+	req.SetPathValue("slug", "part-1")
+	w := httptest.NewRecorder()
+	app.handlePost(w, req)
+
+	body := w.Body.String()
+	if n := strings.Count(body, `rel="next"`); n != 1 {
+		t.Errorf("got %d rel=\"next\" links, want 1", n)
+	}
+	if !strings.Contains(body, `<a href="/posts/part-2" rel="next" class="series-next">`) {
+		t.Error("the series nav should carry rel=\"next\"")
+	}
+	if !strings.Contains(body, `<a href="/posts/newer-post" class="post-nav-next">`) {
+		t.Error("the post nav should still link to the newer post, without rel")
+	}
+}
@@ -21,22 +21,33 @@ import (
 )
 
 type App struct {
-	cfg       Config
-	db        *sql.DB
-	posts     []Post
-	pages     []Page
-	projects  []Project
-	tmpls     map[string]*template.Template
-	md        goldmark.Markdown
-	chromaCSS string
-	limiter   *rateLimiter
-	mu        sync.RWMutex
-	deployMu  sync.Mutex
+	cfg            Config
+	db             *sql.DB
+	posts          []Post
+	pages          []Page
+	nav            []navItem
+	nowHistory     []Page
+	redirects      map[string]string
+	gone           map[string]gonePost
+	revisions      map[string][]revision // git log per post slug, filled on demand
+	ogImages       map[string]ogImage
+	images         *imageSet
+	projects       []Project
+	tags           []Tag
+	postLinks      map[string]postLinks
+	tmpls          map[string]*template.Template
+	md             goldmark.Markdown
+	chromaCSS      string
+	limiter        *rateLimiter
+	suggestLimiter *rateLimiter
+	mu             sync.RWMutex
+	deployMu       sync.Mutex
 }
 
 func Serve() {
 	cfg := LoadConfig()
-	md := newMarkdown()
+	images := &imageSet{}
+	md := newMarkdown(&imageExtension{images: images}, includeExtension{dir: cfg.ContentDir})
 
 	chromaCSS, err := generateChromaCSS()
 	if err != nil {
@@ -50,12 +61,14 @@ func Serve() {
 	defer db.Close()
 
 	app := &App{
-		cfg:       cfg,
-		db:        db,
-		md:        md,
-		chromaCSS: chromaCSS,
-		tmpls:     parseTemplates(cfg),
-		limiter:   newRateLimiter(),
+		cfg:            cfg,
+		db:             db,
+		md:             md,
+		images:         images,
+		chromaCSS:      chromaCSS,
+		tmpls:          parseTemplates(cfg),
+		limiter:        newRateLimiter(),
+		suggestLimiter: newSuggestLimiter(),
 	}
 
 	if err := app.reload(); err != nil {
@@ -65,25 +78,42 @@ func Serve() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /{$}", app.handleHome)
+	mux.HandleFunc("GET /", app.handleFallback)
 	mux.HandleFunc("GET /posts", app.handlePostList)
+	mux.HandleFunc("GET /archive", app.handleArchive)
 	mux.HandleFunc("GET /posts/{slug}", app.handlePost)
+	mux.HandleFunc("GET /posts/{slug}/history", app.handlePostHistory)
 	mux.HandleFunc("POST /posts/{slug}/comments", app.handleCommentSubmit)
 	mux.HandleFunc("GET /projects", app.handleProjectList)
 	mux.HandleFunc("GET /projects/{slug}", app.handleProject)
-mux.HandleFunc("GET /search", app.handleSearch)
+	mux.HandleFunc("GET /projects/{slug}/{page}", app.handleProjectPage)
+	mux.HandleFunc("GET /projects/{slug}/changelog.xml", app.handleProjectChangelogFeed)
+	mux.HandleFunc("GET /now", app.handleNow)
+	mux.HandleFunc("GET /now/archive", app.handleNowArchive)
+	mux.HandleFunc("GET /now/feed.xml", app.handleNowFeed)
+	mux.HandleFunc("GET /now/{date}", app.handleNowSnapshot)
+	mux.HandleFunc("GET /series/{slug}", app.handleSeries)
+	mux.HandleFunc("GET /tags", app.handleTagList)
+	mux.HandleFunc("GET /tags/{tag}", app.handleTag)
+	mux.HandleFunc("GET /search", app.handleSearch)
 	mux.HandleFunc("GET /rss.xml", app.handleRSS)
+	mux.HandleFunc("GET /sitemap.xml", app.handleSitemap)
+	mux.HandleFunc("GET /sitemap/{part}", app.handleSitemapPart)
+	mux.HandleFunc("GET /robots.txt", app.handleRobots)
 	mux.HandleFunc("GET /subscribe", app.handleSubscribeForm)
 	mux.HandleFunc("POST /subscribe", app.handleSubscribe)
 	mux.HandleFunc("GET /subscribe/verify", app.handleSubscribeVerify)
 	mux.HandleFunc("GET /subscribe/remove", app.handleSubscribeRemove)
 	mux.HandleFunc("POST /deploy", app.handleDeploy)
-	mux.HandleFunc("GET /uses", app.handlePage)
-	mux.HandleFunc("GET /now", app.handlePage)
+	mux.HandleFunc("GET /og/{file}", app.handleOGImage)
+	mux.HandleFunc("GET /og/projects/{file}", app.handleProjectOGImage)
 	mux.HandleFunc("GET /static/chroma.css", app.handleChromaCSS)
 	mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+	mux.HandleFunc("GET /img/{file}", app.handleImageVariant)
 	mux.Handle("GET /images/", http.StripPrefix("/images/", http.FileServer(http.Dir(filepath.Join(cfg.ContentDir, "images")))))
 
 	mux.HandleFunc("GET /api/health", app.handleHealth)
+	mux.HandleFunc("GET /api/search/suggest", app.handleSearchSuggest)
 
 	// Admin API
 	mux.HandleFunc("GET /api/admin/stats", app.requireAdmin(app.handleAdminStats))
@@ -152,7 +182,7 @@ func parseTemplates(cfg Config) map[string]*template.Template {
 		},
 	}
 
-	names := []string{"home", "post", "post_list", "page", "project", "project_list", "subscribe", "search", "404"}
+	names := []string{"archive", "changelog", "home", "now", "now_archive", "post", "post_history", "post_list", "page", "project", "project_list", "project_page", "series", "tag", "tag_list", "subscribe", "search", "404", "410"}
 	tmpls := make(map[string]*template.Template, len(names))
 	for _, name := range names {
 		tmpls[name] = template.Must(
@@ -166,12 +196,17 @@ func parseTemplates(cfg Config) map[string]*template.Template {
 	return tmpls
 }
 
-func (app *App) render(w http.ResponseWriter, name string, data any) {
+func (app *App) render(w http.ResponseWriter, name string, data map[string]any) {
 	tmpl, ok := app.tmpls[name]
 	if !ok {
 		http.Error(w, "template not found", http.StatusInternalServerError)
 		return
 	}
+
+	app.mu.RLock()
+	data["Nav"] = app.nav
+	app.mu.RUnlock()
+
 	var buf bytes.Buffer
 	if err := tmpl.ExecuteTemplate(&buf, "base.html", data); err != nil {
 		log.Printf("template error: %v", err)
@@ -213,8 +248,16 @@ func clientIP(r *http.Request) string {
 }
 
 func (app *App) renderNotFound(w http.ResponseWriter, r *http.Request) {
+	if app.redirect(w, r) || app.renderGone(w, r) {
+		return
+	}
+
 	var buf bytes.Buffer
-	if err := app.tmpls["404"].ExecuteTemplate(&buf, "base.html", nil); err != nil {
+	app.mu.RLock()
+	data := map[string]any{"Nav": app.nav}
+	app.mu.RUnlock()
+
+	if err := app.tmpls["404"].ExecuteTemplate(&buf, "base.html", data); err != nil {
 		log.Printf("404 template error: %v", err)
 		http.Error(w, "not found", http.StatusNotFound)
 		return
@@ -0,0 +1,145 @@
+package blog
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sitemapLimit is the most URLs one sitemap may list under the sitemap
+// protocol. Larger sites get a sitemap index.
+var sitemapLimit = 50000
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapURLs lists every public URL on the site. It uses only public posts,
+// regardless of isLocal, so private posts never leak into the sitemap.
+func (app *App) sitemapURLs() []sitemapURL {
+	app.mu.RLock()
+	posts := publicPosts(app.posts)
+	pages := app.pages
+	projects := app.projects
+	meta := app.tags
+	app.mu.RUnlock()
+
+	base := app.cfg.BaseURL
+	lastmod := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+
+	var newest time.Time
+	for _, p := range posts {
+		if m := p.lastModified(); m.After(newest) {
+			newest = m
+		}
+	}
+
+	urls := []sitemapURL{
+		{Loc: base + "/", LastMod: lastmod(newest)},
+		{Loc: base + "/posts", LastMod: lastmod(newest)},
+		{Loc: base + "/archive", LastMod: lastmod(newest)},
+		{Loc: base + "/projects"},
+		{Loc: base + "/tags", LastMod: lastmod(newest)},
+	}
+
+	series := make(map[string]bool)
+	for _, p := range posts {
+		urls = append(urls, sitemapURL{Loc: base + "/posts/" + p.Slug, LastMod: lastmod(p.lastModified())})
+		if p.SeriesSlug != "" && !series[p.SeriesSlug] {
+			series[p.SeriesSlug] = true
+			urls = append(urls, sitemapURL{Loc: base + "/series/" + p.SeriesSlug})
+		}
+	}
+	for _, p := range projects {
+		urls = append(urls, sitemapURL{Loc: base + "/projects/" + p.Slug})
+		for _, sub := range p.Pages {
+			urls = append(urls, sitemapURL{Loc: base + "/projects/" + p.Slug + "/" + sub.Slug})
+		}
+		if len(p.Changelog) > 0 {
+			urls = append(urls, sitemapURL{Loc: base + "/projects/" + p.Slug + "/changelog", LastMod: lastmod(p.Changelog[0].Date)})
+		}
+	}
+	for _, p := range pages {
+		urls = append(urls, sitemapURL{Loc: base + "/" + p.Slug, LastMod: lastmod(p.Updated)})
+	}
+	for _, t := range tagCounts(posts, meta) {
+		urls = append(urls, sitemapURL{Loc: base + "/tags/" + url.PathEscape(t.Name)})
+	}
+	return urls
+}
+
+func (app *App) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	urls := app.sitemapURLs()
+	if len(urls) <= sitemapLimit {
+		writeXML(w, sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls})
+		return
+	}
+
+	index := sitemapIndex{XMLNS: sitemapXMLNS}
+	for n := 1; (n-1)*sitemapLimit < len(urls); n++ {
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: fmt.Sprintf("%s/sitemap/%d.xml", app.cfg.BaseURL, n)})
+	}
+	writeXML(w, index)
+}
+
+// handleSitemapPart serves /sitemap/{n}.xml, one chunk of a site too large
+// for a single sitemap.
+func (app *App) handleSitemapPart(w http.ResponseWriter, r *http.Request) {
+	part, ok := strings.CutSuffix(r.PathValue("part"), ".xml")
+	n, err := strconv.Atoi(part)
+	if !ok || err != nil || n < 1 {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	urls := app.sitemapURLs()
+	start := (n - 1) * sitemapLimit
+	if len(urls) <= sitemapLimit || start >= len(urls) {
+		app.renderNotFound(w, r)
+		return
+	}
+
+	end := min(start+sitemapLimit, len(urls))
+	writeXML(w, sitemapURLSet{XMLNS: sitemapXMLNS, URLs: urls[start:end]})
+}
+
+func writeXML(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
+
+func (app *App) handleRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, `User-agent: *
+Disallow: /api/
+Disallow: /subscribe/verify
+Disallow: /subscribe/remove
+
+Sitemap: %s/sitemap.xml
+`, app.cfg.BaseURL)
+}
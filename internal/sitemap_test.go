@@ -0,0 +1,96 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandleSitemap(t *testing.T) {
+	app := testApp(t)
+	app.posts[1].Tags = []string{"secret-tag"}
+	app.posts[1].SeriesSlug = "secret-series"
+	app.posts[0].Tags = append(app.posts[0].Tags, "go tips")
+
+	req := httptest.NewRequest("GET", "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	app.handleSitemap(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("Content-Type = %q, want application/xml", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"<loc>http://localhost:8080/posts/first-post</loc><lastmod>2026-02-25</lastmod>",
+		"<loc>http://localhost:8080/projects/blog</loc>",
+		"<loc>http://localhost:8080/uses</loc>",
+		"<loc>http://localhost:8080/tags/go</loc>",
+		"<loc>http://localhost:8080/tags/go%20tips</loc>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("sitemap missing %s", want)
+		}
+	}
+	for _, leak := range []string{"private-post", "secret-tag", "secret-series"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("sitemap leaks private %s even though isLocal is true", leak)
+		}
+	}
+}
+
+func TestHandleSitemapIndex(t *testing.T) {
+	app := testApp(t)
+	defer func(n int) { sitemapLimit = n }(sitemapLimit)
+	sitemapLimit = 5
+
+	total := len(app.sitemapURLs())
+	parts := (total + sitemapLimit - 1) / sitemapLimit
+
+	w := httptest.NewRecorder()
+	app.handleSitemap(w, httptest.NewRequest("GET", "/sitemap.xml", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "<sitemapindex") || strings.Count(body, "<sitemap>") != parts {
+		t.Fatalf("want a sitemap index with %d parts:\n%s", parts, body)
+	}
+
+	seen := 0
+	for n := 1; n <= parts+1; n++ {
+		part := strconv.Itoa(n) + ".xml"
+		req := httptest.NewRequest("GET", "/sitemap/"+part, nil)
+		req.SetPathValue("part", part)
+		w := httptest.NewRecorder()
+		app.handleSitemapPart(w, req)
+
+		if n > parts {
+			if w.Code != http.StatusNotFound {
+				t.Errorf("part %d past the end: status = %d, want 404", n, w.Code)
+			}
+			continue
+		}
+		seen += strings.Count(w.Body.String(), "<url>")
+	}
+	if seen != total {
+		t.Errorf("parts list %d URLs, want %d", seen, total)
+	}
+}
+
+func TestHandleRobots(t *testing.T) {
+	app := testApp(t)
+
+	w := httptest.NewRecorder()
+	app.handleRobots(w, httptest.NewRequest("GET", "/robots.txt", nil))
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"Disallow: /api/\n",
+		"Disallow: /subscribe/verify\n",
+		"Disallow: /subscribe/remove\n",
+		"Sitemap: http://localhost:8080/sitemap.xml\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("robots.txt missing %q", want)
+		}
+	}
+}
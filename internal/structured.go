@@ -0,0 +1,93 @@
+package blog
+
+import (
+	"strings"
+)
+
+// siteAuthor is the person behind the site, for JSON-LD and h-card markup.
+const siteAuthor = "Thobias"
+
+// jsonLD is a schema.org object. Templates render it inside a
+// <script type="application/ld+json">, where html/template encodes it as JSON.
+type jsonLD map[string]any
+
+func absURL(base, path string) string {
+	if strings.HasPrefix(path, "/") {
+		return base + path
+	}
+	return path
+}
+
+func authorLD(base string) jsonLD {
+	return jsonLD{"@type": "Person", "name": siteAuthor, "url": base + "/"}
+}
+
+func postLD(base string, p Post) jsonLD {
+	ld := jsonLD{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         p.Title,
+		"url":              base + "/posts/" + p.Slug,
+		"mainEntityOfPage": base + "/posts/" + p.Slug,
+		"datePublished":    p.Date.Format("2006-01-02"),
+		"dateModified":     p.lastModified().Format("2006-01-02"),
+		"author":           authorLD(base),
+	}
+	if p.Description != "" {
+		ld["description"] = p.Description
+	}
+	if len(p.Tags) > 0 {
+		ld["keywords"] = strings.Join(p.Tags, ", ")
+	}
+	ld["image"] = ogImageURL(base, p.Image, p.Slug)
+	return ld
+}
+
+// projectLD describes a project as source code. Projects without a repo
+// get nothing, since codeRepository is the point of the type.
+func projectLD(base string, p Project) jsonLD {
+	if p.Repo == "" {
+		return nil
+	}
+	ld := jsonLD{
+		"@context":       "https://schema.org",
+		"@type":          "SoftwareSourceCode",
+		"name":           p.Title,
+		"url":            base + "/projects/" + p.Slug,
+		"codeRepository": p.Repo,
+		"author":         authorLD(base),
+	}
+	if p.Description != "" {
+		ld["description"] = p.Description
+	}
+	if len(p.Tags) > 0 {
+		ld["keywords"] = strings.Join(p.Tags, ", ")
+	}
+	if !p.Started.IsZero() {
+		ld["dateCreated"] = p.Started.Format("2006-01-02")
+	}
+	return ld
+}
+
+// crumb is one step of a BreadcrumbList. The last step is the current page.
+type crumb struct {
+	Name string
+	Path string
+}
+
+func breadcrumbLD(base string, crumbs ...crumb) jsonLD {
+	items := make([]jsonLD, len(crumbs))
+	for i, c := range crumbs {
+		items[i] = jsonLD{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     c.Name,
+			"item":     base + c.Path,
+		}
+	}
+	return jsonLD{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
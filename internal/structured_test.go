@@ -0,0 +1,103 @@
+package blog
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ldScript = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+// jsonLDTypes decodes every JSON-LD block in body, keyed by @type.
+func jsonLDTypes(t *testing.T, body string) map[string]map[string]any {
+	t.Helper()
+
+	types := make(map[string]map[string]any)
+	for _, m := range ldScript.FindAllStringSubmatch(body, -1) {
+		var v map[string]any
+		if err := json.Unmarshal([]byte(m[1]), &v); err != nil {
+			t.Fatalf("invalid JSON-LD %s: %v", m[1], err)
+		}
+		types[v["@type"].(string)] = v
+	}
+	return types
+}
+
+func TestPostStructuredData(t *testing.T) {
+	app := testApp(t)
+	app.posts[0].Image = "/images/cover.png"
+	app.posts[0].Title = "Go </script> tricks"
+
+	req := httptest.NewRequest("GET", "/posts/first-post", nil)
+	req.SetPathValue("slug", "first-post")
+	w := httptest.NewRecorder()
+	app.handlePost(w, req)
+	body := w.Body.String()
+
+	ld := jsonLDTypes(t, body)
+	post := ld["BlogPosting"]
+	if post == nil {
+		t.Fatal("missing BlogPosting")
+	}
+	if post["headline"] != "Go </script> tricks" || post["datePublished"] != "2026-02-25" || post["keywords"] != "go, web" {
+		t.Errorf("BlogPosting = %v", post)
+	}
+	if post["image"] != "http://localhost:8080/images/cover.png" {
+		t.Errorf("image = %v, want absolute URL", post["image"])
+	}
+	if crumbs := ld["BreadcrumbList"]; crumbs == nil || len(crumbs["itemListElement"].([]any)) != 3 {
+		t.Errorf("BreadcrumbList = %v, want 3 items", crumbs)
+	}
+
+	for _, class := range []string{`class="post h-entry"`, `class="p-name"`, `class="dt-published"`, `class="post-body e-content"`, `class="p-author h-card"`} {
+		if !strings.Contains(body, class) {
+			t.Errorf("post missing microformat %s", class)
+		}
+	}
+}
+
+func TestProjectStructuredData(t *testing.T) {
+	app := testApp(t)
+	app.projects[0].Repo = "https://github.com/thobiasn/blog"
+
+	for _, tt := range []struct {
+		slug     string
+		wantCode bool
+	}{
+		{"blog", true},
+		{"side-project", false},
+	} {
+		req := httptest.NewRequest("GET", "/projects/"+tt.slug, nil)
+		req.SetPathValue("slug", tt.slug)
+		w := httptest.NewRecorder()
+		app.handleProject(w, req)
+
+		ld := jsonLDTypes(t, w.Body.String())
+		code, ok := ld["SoftwareSourceCode"]
+		if ok != tt.wantCode {
+			t.Errorf("%s: SoftwareSourceCode present = %v, want %v", tt.slug, ok, tt.wantCode)
+		}
+		if ok && code["codeRepository"] != "https://github.com/thobiasn/blog" {
+			t.Errorf("%s: codeRepository = %v", tt.slug, code["codeRepository"])
+		}
+		if ld["BreadcrumbList"] == nil {
+			t.Errorf("%s: missing BreadcrumbList", tt.slug)
+		}
+	}
+}
+
+func TestHomeMicroformats(t *testing.T) {
+	app := testApp(t)
+
+	w := httptest.NewRecorder()
+	app.handleHome(w, httptest.NewRequest("GET", "/", nil))
+	body := w.Body.String()
+
+	for _, want := range []string{`class="intro h-card"`, `class="p-name u-url u-uid"`, `<li class="h-entry">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("home missing %s", want)
+		}
+	}
+}
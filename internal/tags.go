@@ -0,0 +1,181 @@
+package blog
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"go.abhg.dev/goldmark/frontmatter"
+)
+
+// Tag is a tag with optional metadata from content/tags/<name>.md.
+type Tag struct {
+	Name        string
+	Title       string
+	Description string
+	Count       int
+	Body        template.HTML
+	issues      contentIssues
+}
+
+// DisplayName is the metadata title if set, otherwise the tag itself.
+func (t Tag) DisplayName() string {
+	if t.Title != "" {
+		return t.Title
+	}
+	return t.Name
+}
+
+// normalizeTag lowercases and hyphenates a tag so "Blog" and "blog" match:
+// " Self Hosted " → "self-hosted"
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// normalizeTags normalizes every tag and drops empties and duplicates.
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = normalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func loadAllTags(dir string, md goldmark.Markdown) ([]Tag, contentIssues, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "tags", "*.md"))
+	if err != nil {
+		return nil, contentIssues{}, err
+	}
+
+	var tags []Tag
+	var issues contentIssues
+	for _, f := range files {
+		t, err := parseTag(f, md)
+		issues.add(t.issues)
+		if err != nil {
+			log.Printf("skipping tag %s: %v", f, err)
+			continue
+		}
+		tags = append(tags, t)
+	}
+	return tags, issues, nil
+}
+
+func parseTag(path string, md goldmark.Markdown) (Tag, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Tag{}, err
+	}
+
+	ctx := parser.NewContext()
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return Tag{}, err
+	}
+
+	var meta struct {
+		Title       string `yaml:"title"`
+		Description string `yaml:"description"`
+	}
+	issues := checkDoc(path, "tag", src, doc, ctx)
+	fm := frontmatter.Get(ctx)
+	if fm != nil {
+		if err := fm.Decode(&meta); err != nil {
+			return Tag{issues: issues}, err
+		}
+	}
+
+	return Tag{
+		Name:        normalizeTag(strings.TrimSuffix(filepath.Base(path), ".md")),
+		Title:       meta.Title,
+		Description: meta.Description,
+		Body:        template.HTML(buf.String()),
+		issues:      issues,
+	}, nil
+}
+
+// tagCounts lists every tag used by posts, most used first, with metadata
+// merged in. Tags that only have a metadata file are left out.
+func tagCounts(posts []Post, meta []Tag) []Tag {
+	counts := make(map[string]int)
+	for _, p := range posts {
+		for _, t := range p.Tags {
+			counts[t]++
+		}
+	}
+
+	tags := make([]Tag, 0, len(counts))
+	for name, n := range counts {
+		t := findTag(meta, name)
+		t.Name = name
+		t.Count = n
+		tags = append(tags, t)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+func findTag(tags []Tag, name string) Tag {
+	for _, t := range tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return Tag{Name: name}
+}
+
+func (app *App) handleTagList(w http.ResponseWriter, r *http.Request) {
+	app.mu.RLock()
+	tags := tagCounts(app.visiblePosts(), app.tags)
+	app.mu.RUnlock()
+
+	app.render(w, "tag_list", map[string]any{
+		"Tags": tags,
+	})
+}
+
+func (app *App) handleTag(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("tag")
+	if norm := normalizeTag(name); norm != name {
+		http.Redirect(w, r, "/tags/"+url.PathEscape(norm), http.StatusMovedPermanently)
+		return
+	}
+
+	app.mu.RLock()
+	posts := filterByTag(app.visiblePosts(), name)
+	tag := findTag(app.tags, name)
+	app.mu.RUnlock()
+
+	if len(posts) == 0 {
+		app.renderNotFound(w, r)
+		return
+	}
+	tag.Count = len(posts)
+
+	app.render(w, "tag", map[string]any{
+		"Tag":   tag,
+		"Posts": posts,
+	})
+}
@@ -0,0 +1,154 @@
+package blog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"go", "go"},
+		{"Blog", "blog"},
+		{"  Self Hosted ", "self-hosted"},
+		{"git-crypt", "git-crypt"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTag(tt.in); got != tt.want {
+			t.Errorf("normalizeTag(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{"Blog", "go", "blog", " ", "Go"})
+	if len(got) != 2 || got[0] != "blog" || got[1] != "go" {
+		t.Errorf("normalizeTags() = %v, want [blog go]", got)
+	}
+	if normalizeTags(nil) != nil {
+		t.Error("normalizeTags(nil) should be nil")
+	}
+}
+
+func TestTagCounts(t *testing.T) {
+	posts := []Post{
+		{Slug: "a", Tags: []string{"go", "web"}},
+		{Slug: "b", Tags: []string{"go"}},
+		{Slug: "c", Tags: []string{"cli"}},
+	}
+	meta := []Tag{
+		{Name: "go", Title: "Go", Description: "Posts about Go"},
+		{Name: "unused", Title: "Unused"},
+	}
+
+	got := tagCounts(posts, meta)
+	if len(got) != 3 {
+		t.Fatalf("got %d tags, want 3: %v", len(got), got)
+	}
+	if got[0].Name != "go" || got[0].Count != 2 || got[0].Title != "Go" {
+		t.Errorf("got[0] = %+v, want go (2) with metadata", got[0])
+	}
+	// Ties sort alphabetically
+	if got[1].Name != "cli" || got[2].Name != "web" {
+		t.Errorf("got[1:] = %v, want cli then web", got[1:])
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Go.md")
+	os.WriteFile(path, []byte(`---
+title: Go
+description: Writing about the Go language
+---
+
+Mostly **backend** work.
+`), 0644)
+
+	tag, err := parseTag(path, newMarkdown())
+	if err != nil {
+		t.Fatalf("parseTag: %v", err)
+	}
+	if tag.Name != "go" {
+		t.Errorf("Name = %q, want %q", tag.Name, "go")
+	}
+	if tag.Title != "Go" || tag.Description != "Writing about the Go language" {
+		t.Errorf("tag = %+v, want title and description", tag)
+	}
+	if !strings.Contains(string(tag.Body), "<strong>backend</strong>") {
+		t.Errorf("Body should contain rendered markdown, got %q", tag.Body)
+	}
+}
+
+func TestHandleTagList(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/tags", nil)
+	w := httptest.NewRecorder()
+	app.handleTagList(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="/tags/go">go</a>`) {
+		t.Error("tag list should link to /tags/go")
+	}
+}
+
+func TestHandleTag(t *testing.T) {
+	app := testApp(t)
+	app.tags = []Tag{{Name: "go", Title: "The Go Language", Description: "Gophers welcome"}}
+
+	req := httptest.NewRequest("GET", "/tags/go", nil)
+	req.SetPathValue("tag", "go")
+	w := httptest.NewRecorder()
+	app.handleTag(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "First Post") {
+		t.Error("tag page should list 'First Post'")
+	}
+	if !strings.Contains(body, "The Go Language") || !strings.Contains(body, "Gophers welcome") {
+		t.Error("tag page should render tag metadata")
+	}
+}
+
+func TestHandleTagRedirectsToNormalized(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/tags/Go", nil)
+	req.SetPathValue("tag", "Go")
+	w := httptest.NewRecorder()
+	app.handleTag(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusMovedPermanently)
+	}
+	if loc := w.Header().Get("Location"); loc != "/tags/go" {
+		t.Errorf("Location = %q, want %q", loc, "/tags/go")
+	}
+}
+
+func TestHandleTagNotFound(t *testing.T) {
+	app := testApp(t)
+
+	req := httptest.NewRequest("GET", "/tags/nope", nil)
+	req.SetPathValue("tag", "nope")
+	w := httptest.NewRecorder()
+	app.handleTag(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}